@@ -10,12 +10,20 @@ import (
 )
 
 type confirmBatchInput struct {
-	/* The confirmBatch input also has a 0th input which is not used. A new
-	type could be created and added here if it becomes necessary.*/
-
+	BatchHeader                 batchHeader                 // input 0
 	NonSignerStakesAndSignature nonSignerStakesAndSignature // input 1
 }
 
+type batchHeader struct {
+	BlobHeadersRoot [32]byte `json:"blobHeadersRoot"`
+	// QuorumNumbers has one byte per quorum of the batch
+	QuorumNumbers []byte `json:"quorumNumbers"`
+	// SignedStakeForQuorums has the percentage of stake that signed the
+	// batch, for each quorum of QuorumNumbers
+	SignedStakeForQuorums []byte `json:"signedStakeForQuorums"`
+	ReferenceBlockNumber  uint32 `json:"referenceBlockNumber"`
+}
+
 type nonSignerStakesAndSignature struct {
 	NonSignerQuorumBitmapIndices []uint32   `json:"nonSignerQuorumBitmapIndices"`
 	NonSignerPubkeys             []g1Point  `json:"nonSignerPubkeys"`
@@ -53,9 +61,20 @@ func unpackConfirmBatchInput(avsEnv string, data []byte) (*confirmBatchInput, er
 		return nil, fmt.Errorf("failed to unpack confirmBatch input: %v", err)
 	}
 
+	// Unpack batchHeader
+	var batchHeader batchHeader
+	jsonRaw, err := json.Marshal(inputs[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batchHeader: %v", err)
+	}
+	err = json.Unmarshal(jsonRaw, &batchHeader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal batchHeader: %v", err)
+	}
+
 	// Unpack nonSignerStakesAndSignature
 	var nonSignerStakesAndSignature nonSignerStakesAndSignature
-	jsonRaw, err := json.Marshal(inputs[1])
+	jsonRaw, err = json.Marshal(inputs[1])
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal nonSignerStakesAndSignature: %v", err)
 	}
@@ -65,6 +84,7 @@ func unpackConfirmBatchInput(avsEnv string, data []byte) (*confirmBatchInput, er
 	}
 
 	return &confirmBatchInput{
+		BatchHeader:                 batchHeader,
 		NonSignerStakesAndSignature: nonSignerStakesAndSignature,
 	}, nil
 }
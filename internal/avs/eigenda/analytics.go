@@ -0,0 +1,180 @@
+package eigenda
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda/contracts"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// networkAnalytics keeps the non-signers of every batch within a time window,
+// including the operators that are not tracked, to compare the tracked
+// operators with the rest of the network.
+type networkAnalytics struct {
+	window time.Duration
+	topN   int
+	// operators caches the address of the operators by operatorId, as the
+	// BLS public key of an operator cannot change once registered
+	operators map[common.Hash]common.Address
+	// batches are the non-signers of the batches within the window, from
+	// oldest to newest
+	batches []analyticsBatch
+	// missed is the number of batches within the window missed by each
+	// operator
+	missed map[common.Address]int
+	// recorded is the number of recorded batches
+	recorded uint64
+}
+
+type analyticsBatch struct {
+	// seq identifies the batch among the recorded ones
+	seq        uint64
+	time       time.Time
+	nonSigners []common.Address
+}
+
+func newNetworkAnalytics(c config.NetworkAnalyticsConfig) *networkAnalytics {
+	if !c.Enabled {
+		return nil
+	}
+	a := &networkAnalytics{
+		window:    c.Window,
+		topN:      c.TopN,
+		operators: make(map[common.Hash]common.Address),
+		missed:    make(map[common.Address]int),
+	}
+	if a.window == 0 {
+		a.window = config.DefaultNetworkAnalyticsWindow
+	}
+	if a.topN == 0 {
+		a.topN = config.DefaultNetworkAnalyticsTopN
+	}
+	return a
+}
+
+// recordNonSigners resolves the non-signers of a batch confirmed at batchTime
+// to operator addresses and updates the network-wide metrics. The batch is
+// removed from the window if its block is rolled back, while the distribution
+// is only observed the first time the batch is processed.
+func (e *eigenDAOnChainExporter) recordNonSigners(log types.Log, nonSignerPubkeys []g1Point, batchTime time.Time, replayed bool) error {
+	if e.analytics == nil {
+		return nil
+	}
+	nonSigners := make([]common.Address, 0, len(nonSignerPubkeys))
+	for _, pubkey := range nonSignerPubkeys {
+		operator, err := e.operatorAddress(pubkey.operatorID())
+		if err != nil {
+			return err
+		}
+		nonSigners = append(nonSigners, operator)
+	}
+	metricNetworkBatchNonSigners.WithLabelValues(e.network).Set(float64(len(nonSigners)))
+	if !replayed {
+		metricNetworkBatchNonSignersDistribution.WithLabelValues(e.network).Observe(float64(len(nonSigners)))
+	}
+
+	a := e.analytics
+	a.recorded++
+	batch := analyticsBatch{seq: a.recorded, time: batchTime, nonSigners: nonSigners}
+	a.batches = append(a.batches, batch)
+	for _, operator := range nonSigners {
+		a.missed[operator]++
+	}
+	e.journal.onRollback(log.BlockNumber, func() {
+		// The batches of the newer blocks were already removed, so the batch
+		// is the last one unless it left the window
+		if n := len(a.batches); n > 0 && a.batches[n-1].seq == batch.seq {
+			a.forget(batch)
+			a.batches = a.batches[:n-1]
+			metricNetworkUniqueNonSigners.WithLabelValues(e.network).Set(float64(len(a.missed)))
+			e.updateMissedBatchesLeaderboard()
+		}
+	})
+	a.expire(batchTime)
+
+	metricNetworkUniqueNonSigners.WithLabelValues(e.network).Set(float64(len(a.missed)))
+	e.updateMissedBatchesLeaderboard()
+	return nil
+}
+
+// updateAnalyticsWindow forgets the batches out of the window ending now, so
+// the network-wide metrics keep moving when no batch is confirmed.
+func (e *eigenDAOnChainExporter) updateAnalyticsWindow(now time.Time) {
+	if e.analytics == nil {
+		return
+	}
+	e.analytics.expire(now)
+	metricNetworkUniqueNonSigners.WithLabelValues(e.network).Set(float64(len(e.analytics.missed)))
+	e.updateMissedBatchesLeaderboard()
+}
+
+// expire forgets the batches out of the window ending at the given time.
+func (a *networkAnalytics) expire(now time.Time) {
+	expired := 0
+	for expired < len(a.batches) && now.Sub(a.batches[expired].time) > a.window {
+		a.forget(a.batches[expired])
+		expired++
+	}
+	a.batches = a.batches[expired:]
+}
+
+// forget removes the non-signers of the batch from the missed batches.
+func (a *networkAnalytics) forget(batch analyticsBatch) {
+	for _, operator := range batch.nonSigners {
+		a.missed[operator]--
+		if a.missed[operator] == 0 {
+			delete(a.missed, operator)
+		}
+	}
+}
+
+// updateMissedBatchesLeaderboard exports the operators that missed the most
+// batches within the window.
+func (e *eigenDAOnChainExporter) updateMissedBatchesLeaderboard() {
+	a := e.analytics
+	operators := make([]common.Address, 0, len(a.missed))
+	for operator := range a.missed {
+		operators = append(operators, operator)
+	}
+	slices.SortFunc(operators, func(x, y common.Address) int {
+		if a.missed[x] != a.missed[y] {
+			return a.missed[y] - a.missed[x]
+		}
+		return x.Cmp(y)
+	})
+	if len(operators) > a.topN {
+		operators = operators[:a.topN]
+	}
+	metricNetworkTopMissedBatches.DeletePartialMatch(prometheus.Labels{"network": e.network})
+	for _, operator := range operators {
+		metricNetworkTopMissedBatches.WithLabelValues(e.network, operator.Hex()).Set(float64(a.missed[operator]))
+	}
+}
+
+// operatorAddress returns the address of the operator with the given
+// operatorId, from the cache or from the BLSApkRegistry.
+func (e *eigenDAOnChainExporter) operatorAddress(operatorID common.Hash) (common.Address, error) {
+	if operator, ok := e.analytics.operators[operatorID]; ok {
+		return operator, nil
+	}
+	blsApkRegistryContract, err := contracts.GetBlsApkRegistryContract(e.avsEnv)
+	if err != nil {
+		return common.Address{}, err
+	}
+	outputs, err := e.callContract(rpc.WithPriority(context.Background(), rpc.PriorityLow), blsApkRegistryContract.Address, blsApkRegistryContract.Abi, nil, "pubkeyHashToOperator", operatorID)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to resolve operator %s: %v", operatorID, err)
+	}
+	operator := outputs[0].(common.Address)
+	slog.Debug("resolved non-signer operator |", "avsEnv", e.avsEnv, "operatorId", operatorID, "operator", operator)
+	e.analytics.operators[operatorID] = operator
+	return operator, nil
+}
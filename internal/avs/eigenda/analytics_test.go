@@ -0,0 +1,95 @@
+package eigenda
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordNonSigners(t *testing.T) {
+	a := g1Point{X: big.NewInt(1), Y: big.NewInt(2)}
+	b := g1Point{X: big.NewInt(3), Y: big.NewInt(4)}
+	addressA, addressB := common.HexToAddress("0x0a"), common.HexToAddress("0x0b")
+	start := time.Unix(1_700_000_000, 0)
+	tests := []struct {
+		name     string
+		rollback uint64
+		missed   map[common.Address]int
+		batches  int
+	}{
+		{name: "no rollback", rollback: 100, missed: map[common.Address]int{addressA: 1, addressB: 2}, batches: 2},
+		{name: "rollback of the last batch", rollback: 12, missed: map[common.Address]int{addressB: 1}, batches: 1},
+		{name: "rollback of every batch", rollback: 11, missed: map[common.Address]int{}, batches: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestExporter(t, nil)
+			e.analytics = newNetworkAnalytics(config.NetworkAnalyticsConfig{Enabled: true, Window: time.Hour})
+			e.analytics.operators[a.operatorID()] = addressA
+			e.analytics.operators[b.operatorID()] = addressB
+			require.NoError(t, e.recordNonSigners(types.Log{BlockNumber: 11}, []g1Point{b}, start, false))
+			require.NoError(t, e.recordNonSigners(types.Log{BlockNumber: 12}, []g1Point{a, b}, start.Add(time.Minute), false))
+
+			e.journal.rollback(tt.rollback)
+
+			assert.Equal(t, tt.missed, e.analytics.missed)
+			assert.Len(t, e.analytics.batches, tt.batches)
+		})
+	}
+}
+
+func TestRecordNonSignersWindow(t *testing.T) {
+	a := g1Point{X: big.NewInt(1), Y: big.NewInt(2)}
+	addressA := common.HexToAddress("0x0a")
+	start := time.Unix(1_700_000_000, 0)
+	e := newTestExporter(t, nil)
+	e.analytics = newNetworkAnalytics(config.NetworkAnalyticsConfig{Enabled: true, Window: time.Hour})
+	e.analytics.operators[a.operatorID()] = addressA
+	require.NoError(t, e.recordNonSigners(types.Log{BlockNumber: 11}, []g1Point{a}, start, false))
+	require.NoError(t, e.recordNonSigners(types.Log{BlockNumber: 12}, nil, start.Add(2*time.Hour), false))
+	assert.Empty(t, e.analytics.missed, "the first batch left the window")
+
+	// The batch out of the window is not removed again
+	e.journal.rollback(11)
+
+	assert.Empty(t, e.analytics.missed)
+	assert.Empty(t, e.analytics.batches)
+}
+
+func TestUpdateAnalyticsWindow(t *testing.T) {
+	a := g1Point{X: big.NewInt(1), Y: big.NewInt(2)}
+	addressA := common.HexToAddress("0x0a")
+	start := time.Unix(1_700_000_000, 0)
+	tests := []struct {
+		name    string
+		now     time.Time
+		missed  map[common.Address]int
+		batches int
+	}{
+		{name: "batches within the window", now: start.Add(59 * time.Minute), missed: map[common.Address]int{addressA: 2}, batches: 2},
+		{name: "first batch out of the window", now: start.Add(61 * time.Minute), missed: map[common.Address]int{addressA: 1}, batches: 1},
+		{name: "every batch out of the window", now: start.Add(2 * time.Hour), missed: map[common.Address]int{}, batches: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestExporter(t, nil)
+			e.analytics = newNetworkAnalytics(config.NetworkAnalyticsConfig{Enabled: true, Window: time.Hour})
+			e.analytics.operators[a.operatorID()] = addressA
+			require.NoError(t, e.recordNonSigners(types.Log{BlockNumber: 11}, []g1Point{a}, start, false))
+			require.NoError(t, e.recordNonSigners(types.Log{BlockNumber: 12}, []g1Point{a}, start.Add(10*time.Minute), false))
+
+			// No batch is confirmed while the clock advances
+			e.updateAnalyticsWindow(tt.now)
+
+			assert.Equal(t, tt.missed, e.analytics.missed)
+			assert.Len(t, e.analytics.batches, tt.batches)
+			assert.Equal(t, float64(len(tt.missed)), gaugeValue(t, metricNetworkUniqueNonSigners.WithLabelValues(e.network)))
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package eigenda
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda/contracts"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// trackBatchID checks the ID of a confirmed batch follows the ID of the last
+// processed batch, reporting the skipped IDs as a gap. The previous ID is
+// restored if the block of the batch is rolled back, so the batches processed
+// again are checked against the last batch still in the chain. It returns the
+// ID of the batch.
+func (e *eigenDAOnChainExporter) trackBatchID(log types.Log) (uint32, error) {
+	serviceManagerContract, err := contracts.GetServiceManagerContract(e.avsEnv)
+	if err != nil {
+		return 0, err
+	}
+	logInputs, err := serviceManagerContract.Abi.Events["BatchConfirmed"].Inputs.NonIndexed().Unpack(log.Data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to unpack batch confirmed log: %v", err)
+	}
+	batchID := logInputs[0].(uint32)
+
+	if e.lastBatchID != nil && batchID > *e.lastBatchID+1 {
+		slog.Error("batch ID gap detected |", "avsEnv", e.avsEnv, "blockNumber", log.BlockNumber, "fromBatchId", *e.lastBatchID+1, "toBatchId", batchID-1)
+		e.journal.add(log.BlockNumber, metricOnchainBatchIDGaps, metricOnchainBatchIDGapsRolledBack, e.network)
+	}
+	previous := e.lastBatchID
+	e.journal.onRollback(log.BlockNumber, func() {
+		e.lastBatchID = previous
+		if previous == nil {
+			metricOnchainLastBatchID.DeleteLabelValues(e.network)
+			return
+		}
+		metricOnchainLastBatchID.WithLabelValues(e.network).Set(float64(*previous))
+	})
+	e.lastBatchID = &batchID
+	metricOnchainLastBatchID.WithLabelValues(e.network).Set(float64(batchID))
+	return batchID, nil
+}
+
+// reconcileBatchID compares the ID of the last processed batch with the ID of
+// the last batch confirmed onchain up to the given processed block, to detect
+// batches whose logs were not processed.
+func (e *eigenDAOnChainExporter) reconcileBatchID(blockNumber *big.Int) error {
+	if e.lastBatchID == nil {
+		slog.Debug("no batch processed yet, skipping batch ID reconciliation |", "avsEnv", e.avsEnv)
+		return nil
+	}
+	serviceManagerContract, err := contracts.GetServiceManagerContract(e.avsEnv)
+	if err != nil {
+		return err
+	}
+	outputs, err := e.callContract(context.Background(), serviceManagerContract.Address, serviceManagerContract.Abi, blockNumber, "batchId")
+	if err != nil {
+		return err
+	}
+	// batchId is the ID of the next batch
+	nextBatchID := outputs[0].(uint32)
+	unseen := 0
+	if nextBatchID > *e.lastBatchID+1 {
+		unseen = int(nextBatchID - *e.lastBatchID - 1)
+		slog.Error("confirmed batches were not processed |", "avsEnv", e.avsEnv, "blockNumber", blockNumber, "lastBatchId", *e.lastBatchID, "onchainLastBatchId", nextBatchID-1)
+	}
+	metricOnchainUnseenBatches.WithLabelValues(e.network).Set(float64(unseen))
+	return nil
+}
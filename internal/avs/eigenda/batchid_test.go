@@ -0,0 +1,62 @@
+package eigenda
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda/contracts"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackBatchID(t *testing.T) {
+	serviceManagerContract, err := contracts.GetServiceManagerContract(config.AVSEnvEigenDAHolesky)
+	require.NoError(t, err)
+	batchConfirmed := serviceManagerContract.Abi.Events["BatchConfirmed"]
+	batchLog := func(number uint64, batchID uint32) types.Log {
+		data, err := batchConfirmed.Inputs.NonIndexed().Pack(batchID)
+		require.NoError(t, err)
+		return types.Log{BlockNumber: number, Data: data}
+	}
+	tests := []struct {
+		name       string
+		batchIDs   []uint32
+		rollback   uint64
+		lastID     *uint32
+		replayID   *uint32
+		gaps       float64
+		rolledBack float64
+	}{
+		{name: "rollback of the last batch", batchIDs: []uint32{1, 2}, rollback: 12, lastID: ptr(uint32(1))},
+		{name: "rollback of every batch", batchIDs: []uint32{1, 2}, rollback: 11, lastID: nil},
+		{name: "replay with a gap", batchIDs: []uint32{1, 2}, rollback: 12, lastID: ptr(uint32(1)), replayID: ptr(uint32(3)), gaps: 1},
+		{name: "replay across a gap", batchIDs: []uint32{1, 3}, rollback: 12, lastID: ptr(uint32(1)), replayID: ptr(uint32(3)), gaps: 1, rolledBack: 1},
+		{name: "replay closing a gap", batchIDs: []uint32{1, 3}, rollback: 12, lastID: ptr(uint32(1)), replayID: ptr(uint32(2)), gaps: 0, rolledBack: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestExporter(t, nil)
+			network := e.network
+			// The gaps counters are global, so only their increase is checked
+			gaps := counterValue(t, metricOnchainBatchIDGaps.WithLabelValues(network))
+			rolledBack := counterValue(t, metricOnchainBatchIDGapsRolledBack.WithLabelValues(network))
+			for i, batchID := range tt.batchIDs {
+				_, err := e.trackBatchID(batchLog(uint64(11+i), batchID))
+				require.NoError(t, err)
+			}
+
+			e.journal.rollback(tt.rollback)
+
+			assert.Equal(t, tt.lastID, e.lastBatchID)
+			if tt.replayID != nil {
+				// The batch replacing the rolled back one
+				_, err := e.trackBatchID(batchLog(tt.rollback, *tt.replayID))
+				require.NoError(t, err)
+			}
+			e.journal.flush(12)
+			assert.Equal(t, gaps+tt.gaps, counterValue(t, metricOnchainBatchIDGaps.WithLabelValues(network)))
+			assert.Equal(t, rolledBack+tt.rolledBack, counterValue(t, metricOnchainBatchIDGapsRolledBack.WithLabelValues(network)))
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package eigenda
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// bitmapCacheBlocks is the number of reference blocks whose quorum bitmaps are
+// cached.
+const bitmapCacheBlocks = 64
+
+// bitmapCache caches the quorum bitmaps of the operators by reference block.
+// The bitmap of an operator at a block does not change, unless the block is
+// reorged.
+type bitmapCache struct {
+	blocks map[uint32]map[common.Hash]*big.Int
+}
+
+// get returns the cached bitmap of the operator at the reference block.
+func (c *bitmapCache) get(operatorID common.Hash, referenceBlock uint32) (*big.Int, bool) {
+	bitmap, ok := c.blocks[referenceBlock][operatorID]
+	return bitmap, ok
+}
+
+// set caches the bitmap of the operator at the reference block, evicting the
+// oldest reference block when the cache is full.
+func (c *bitmapCache) set(operatorID common.Hash, referenceBlock uint32, bitmap *big.Int) {
+	if c.blocks == nil {
+		c.blocks = make(map[uint32]map[common.Hash]*big.Int)
+	}
+	if _, ok := c.blocks[referenceBlock]; !ok {
+		if len(c.blocks) >= bitmapCacheBlocks {
+			oldest := referenceBlock
+			for block := range c.blocks {
+				oldest = min(oldest, block)
+			}
+			delete(c.blocks, oldest)
+		}
+		c.blocks[referenceBlock] = make(map[common.Hash]*big.Int)
+	}
+	c.blocks[referenceBlock][operatorID] = bitmap
+}
+
+// rollback drops the bitmaps of the reference blocks from the given block, as
+// they may have changed with a reorg.
+func (c *bitmapCache) rollback(from uint64) {
+	for block := range c.blocks {
+		if uint64(block) >= from {
+			delete(c.blocks, block)
+		}
+	}
+}
+
+// quorumBitmaps returns the bitmaps of the quorums the tracked operators were
+// registered in at the reference block. The indices of the bitmaps of the
+// non-signers are part of the batch, while the indices of the other operators
+// are read with a single call. The bitmaps are then read concurrently, and
+// cached by reference block.
+func (e *eigenDAOnChainExporter) quorumBitmaps(referenceBlock uint32, nonSignerBitmapIndices map[*trackedOperator]uint32) (map[*trackedOperator]*big.Int, error) {
+	bitmaps := make(map[*trackedOperator]*big.Int, len(e.operators))
+	indices := make(map[*trackedOperator]uint32)
+	var signers []*trackedOperator
+	for _, operator := range e.operators {
+		if bitmap, ok := e.bitmaps.get(operator.id, referenceBlock); ok {
+			bitmaps[operator] = bitmap
+		} else if index, ok := nonSignerBitmapIndices[operator]; ok {
+			indices[operator] = index
+		} else {
+			signers = append(signers, operator)
+		}
+	}
+	if len(signers) > 0 {
+		signerIndices, err := e.quorumBitmapIndices(signers, referenceBlock)
+		if err != nil {
+			return nil, err
+		}
+		for _, operator := range signers {
+			index, ok := signerIndices[operator]
+			if !ok {
+				// The operator was never registered before the reference
+				// block
+				bitmaps[operator] = new(big.Int)
+				e.bitmaps.set(operator.id, referenceBlock, bitmaps[operator])
+				continue
+			}
+			indices[operator] = index
+		}
+	}
+
+	operatorCh := make(chan *trackedOperator, len(indices))
+	for operator := range indices {
+		operatorCh <- operator
+	}
+	close(operatorCh)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for range min(e.txFetchConcurrency, len(indices)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for operator := range operatorCh {
+				bitmap, err := e.quorumBitmapAtIndex(operator.id, referenceBlock, indices[operator])
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("failed to get quorums of operator %s: %v", operator.Name, err)
+					}
+				} else {
+					bitmaps[operator] = bitmap
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	for operator := range indices {
+		e.bitmaps.set(operator.id, referenceBlock, bitmaps[operator])
+	}
+	return bitmaps, nil
+}
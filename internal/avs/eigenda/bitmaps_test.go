@@ -0,0 +1,121 @@
+package eigenda
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda/contracts"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// quorumBitmaps returns the CallContract function of an RPC client answering
+// the quorum bitmap calls of the RegistryCoordinator with the registered
+// bitmaps, counting the calls by method.
+func quorumBitmaps(t *testing.T, registry *contracts.RegistryCoordinatorContract, bitmaps map[common.Hash]*big.Int, calls map[string]int) func(msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	var mu sync.Mutex
+	return func(msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+		method, err := registry.Abi.MethodById(msg.Data[:4])
+		require.NoError(t, err)
+		args, err := method.Inputs.Unpack(msg.Data[4:])
+		require.NoError(t, err)
+		mu.Lock()
+		calls[method.Name]++
+		mu.Unlock()
+		switch method.Name {
+		case "getQuorumBitmapIndicesAtBlockNumber":
+			operatorIDs := args[1].([][32]byte)
+			indices := make([]uint32, len(operatorIDs))
+			for i, operatorID := range operatorIDs {
+				if _, ok := bitmaps[operatorID]; !ok {
+					return nil, revertedError{}
+				}
+				indices[i] = uint32(i)
+			}
+			return method.Outputs.Pack(indices)
+		case "getQuorumBitmapAtBlockNumberByIndex":
+			return method.Outputs.Pack(bitmaps[args[0].([32]byte)])
+		}
+		t.Fatalf("unexpected call to %s", method.Name)
+		return nil, nil
+	}
+}
+
+func TestQuorumBitmaps(t *testing.T) {
+	signer := &trackedOperator{OperatorConfig: config.OperatorConfig{Name: "signer"}, id: common.HexToHash("0x01")}
+	nonSigner := &trackedOperator{OperatorConfig: config.OperatorConfig{Name: "non-signer"}, id: common.HexToHash("0x02")}
+	unregistered := &trackedOperator{OperatorConfig: config.OperatorConfig{Name: "unregistered"}, id: common.HexToHash("0x03")}
+	tests := []struct {
+		name      string
+		operators []*trackedOperator
+		calls     map[string]int
+	}{
+		{
+			name:      "registered signers",
+			operators: []*trackedOperator{signer, nonSigner},
+			calls:     map[string]int{"getQuorumBitmapIndicesAtBlockNumber": 1, "getQuorumBitmapAtBlockNumberByIndex": 2},
+		},
+		{
+			name:      "unregistered signer",
+			operators: []*trackedOperator{signer, nonSigner, unregistered},
+			calls:     map[string]int{"getQuorumBitmapIndicesAtBlockNumber": 3, "getQuorumBitmapAtBlockNumberByIndex": 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeRpc{}
+			e := newTestExporter(t, client, tt.operators...)
+			e.txFetchConcurrency = 2
+			calls := make(map[string]int)
+			client.callContract = quorumBitmaps(t, e.registryCoordinator, map[common.Hash]*big.Int{
+				signer.id:    big.NewInt(0b01),
+				nonSigner.id: big.NewInt(0b11),
+			}, calls)
+			// The signer does not sign, so its bitmap index comes from the
+			// batch
+			nonSignerBitmapIndices := map[*trackedOperator]uint32{nonSigner: 0}
+
+			bitmaps, err := e.quorumBitmaps(100, nonSignerBitmapIndices)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.calls, calls)
+			assert.Equal(t, big.NewInt(0b01), bitmaps[signer])
+			assert.Equal(t, big.NewInt(0b11), bitmaps[nonSigner])
+			if len(tt.operators) == 3 {
+				assert.Zero(t, bitmaps[unregistered].Sign())
+			}
+
+			// The bitmaps at the same reference block are cached
+			clear(calls)
+			cached, err := e.quorumBitmaps(100, nonSignerBitmapIndices)
+
+			require.NoError(t, err)
+			assert.Empty(t, calls)
+			assert.Equal(t, bitmaps, cached)
+		})
+	}
+}
+
+func TestBitmapCache(t *testing.T) {
+	operatorID := common.HexToHash("0x01")
+	var c bitmapCache
+	for block := uint32(1); block <= bitmapCacheBlocks+1; block++ {
+		c.set(operatorID, block, big.NewInt(int64(block)))
+	}
+
+	_, ok := c.get(operatorID, 1)
+	assert.False(t, ok, "the oldest reference block is evicted")
+	bitmap, ok := c.get(operatorID, 2)
+	require.True(t, ok)
+	assert.Equal(t, big.NewInt(2), bitmap)
+
+	c.rollback(bitmapCacheBlocks)
+	_, ok = c.get(operatorID, bitmapCacheBlocks)
+	assert.False(t, ok, "the reorged reference blocks are dropped")
+	_, ok = c.get(operatorID, bitmapCacheBlocks-1)
+	assert.True(t, ok)
+}
@@ -0,0 +1,31 @@
+package eigenda
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// callContract calls a view method of a contract at the given block, or at the
+// latest block if blockNumber is nil, and returns its unpacked outputs. RPC
+// errors are wrapped so reverted calls can be told apart with
+// rpc.IsExecutionReverted.
+func (e *eigenDAOnChainExporter) callContract(ctx context.Context, address common.Address, contractAbi abi.ABI, blockNumber *big.Int, method string, args ...interface{}) ([]interface{}, error) {
+	data, err := contractAbi.Pack(method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack %s call: %v", method, err)
+	}
+	out, err := e.ethClient.CallContract(ctx, ethereum.CallMsg{To: &address, Data: data}, blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	outputs, err := contractAbi.Unpack(method, out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack %s output: %v", method, err)
+	}
+	return outputs, nil
+}
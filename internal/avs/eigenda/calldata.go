@@ -0,0 +1,75 @@
+package eigenda
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda/contracts"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// errUndecodableBatch is returned when the confirmBatch call of a batch cannot
+// be found, so processing the batch again does not help.
+var errUndecodableBatch = errors.New("undecodable batch")
+
+// confirmBatchCalldata returns the arguments of the confirmBatch call of the
+// transaction. If the transaction is not a direct confirmBatch call, for example
+// when the batch confirmer goes through a multisig, a proxy or a multicall, the
+// call is looked up in the call tree of the transaction.
+func (e *eigenDAOnChainExporter) confirmBatchCalldata(tx *types.Transaction) ([]byte, error) {
+	serviceManagerContract, err := contracts.GetServiceManagerContract(e.avsEnv)
+	if err != nil {
+		return nil, err
+	}
+	selector := serviceManagerContract.Abi.Methods["confirmBatch"].ID
+
+	if isCallTo(tx.To(), tx.Data(), serviceManagerContract.Address, selector) {
+		return tx.Data()[4:], nil
+	}
+
+	frame, err := e.ethClient.TraceTransaction(rpc.WithPriority(context.Background(), rpc.PriorityLow), tx.Hash())
+	if err != nil {
+		// Endpoints without the debug namespace never trace the transaction,
+		// while other failures are worth processing the batch again
+		if rpc.IsPermanent(err) {
+			return nil, fmt.Errorf("%w: failed to trace transaction: %v", errUndecodableBatch, err)
+		}
+		return nil, fmt.Errorf("failed to trace transaction: %v", err)
+	}
+	calls := findCalls(frame, serviceManagerContract.Address, selector)
+	switch len(calls) {
+	case 0:
+		return nil, fmt.Errorf("%w: no confirmBatch call to the ServiceManager found in transaction %s", errUndecodableBatch, tx.Hash())
+	case 1:
+		return calls[0].Input[4:], nil
+	default:
+		return nil, fmt.Errorf("%w: %d confirmBatch calls to the ServiceManager found in transaction %s", errUndecodableBatch, len(calls), tx.Hash())
+	}
+}
+
+// findCalls returns the successful calls of the call tree to the method of the
+// contract with the given selector. Calls made from reverted calls are
+// ignored, as their effects were reverted.
+func findCalls(frame *rpc.CallFrame, to common.Address, selector []byte) []*rpc.CallFrame {
+	if frame.Error != "" {
+		return nil
+	}
+	var calls []*rpc.CallFrame
+	if frame.Type != "DELEGATECALL" && isCallTo(frame.To, frame.Input, to, selector) {
+		calls = append(calls, frame)
+	}
+	for i := range frame.Calls {
+		calls = append(calls, findCalls(&frame.Calls[i], to, selector)...)
+	}
+	return calls
+}
+
+// isCallTo reports whether the call is made to the method of the contract
+// with the given selector.
+func isCallTo(callTo *common.Address, input []byte, to common.Address, selector []byte) bool {
+	return callTo != nil && *callTo == to && len(input) >= 4 && bytes.Equal(input[:4], selector)
+}
@@ -0,0 +1,165 @@
+package eigenda
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda/contracts"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindCalls(t *testing.T) {
+	serviceManager := common.HexToAddress("0x5e")
+	proxy := common.HexToAddress("0x01")
+	other := common.HexToAddress("0x02")
+	selector := []byte{0xde, 0xad, 0xbe, 0xef}
+	confirmBatch := append(append([]byte{}, selector...), 0x01)
+	tests := []struct {
+		name  string
+		frame rpc.CallFrame
+		calls int
+	}{
+		{
+			name:  "direct call",
+			frame: rpc.CallFrame{Type: "CALL", To: &serviceManager, Input: confirmBatch},
+			calls: 1,
+		},
+		{
+			name: "nested call",
+			frame: rpc.CallFrame{Type: "CALL", To: &proxy, Input: []byte{0x01}, Calls: []rpc.CallFrame{
+				{Type: "CALL", To: &other, Input: []byte{0x02}, Calls: []rpc.CallFrame{
+					{Type: "CALL", To: &serviceManager, Input: confirmBatch},
+				}},
+			}},
+			calls: 1,
+		},
+		{
+			name: "call to the implementation of the ServiceManager proxy",
+			frame: rpc.CallFrame{Type: "CALL", To: &serviceManager, Input: confirmBatch, Calls: []rpc.CallFrame{
+				{Type: "DELEGATECALL", To: &serviceManager, Input: confirmBatch},
+			}},
+			calls: 1,
+		},
+		{
+			name: "delegate call from another contract",
+			frame: rpc.CallFrame{Type: "CALL", To: &proxy, Input: []byte{0x01}, Calls: []rpc.CallFrame{
+				{Type: "DELEGATECALL", To: &serviceManager, Input: confirmBatch},
+			}},
+			calls: 0,
+		},
+		{
+			name: "reverted call",
+			frame: rpc.CallFrame{Type: "CALL", To: &proxy, Input: []byte{0x01}, Calls: []rpc.CallFrame{
+				{Type: "CALL", To: &serviceManager, Input: confirmBatch, Error: "execution reverted"},
+			}},
+			calls: 0,
+		},
+		{
+			name: "call from a reverted call",
+			frame: rpc.CallFrame{Type: "CALL", To: &proxy, Input: []byte{0x01}, Calls: []rpc.CallFrame{
+				{Type: "CALL", To: &other, Input: []byte{0x02}, Error: "out of gas", Calls: []rpc.CallFrame{
+					{Type: "CALL", To: &serviceManager, Input: confirmBatch},
+				}},
+			}},
+			calls: 0,
+		},
+		{
+			name:  "other method",
+			frame: rpc.CallFrame{Type: "CALL", To: &serviceManager, Input: []byte{0x01, 0x02, 0x03, 0x04}},
+			calls: 0,
+		},
+		{
+			name:  "short input",
+			frame: rpc.CallFrame{Type: "CALL", To: &serviceManager, Input: selector[:3]},
+			calls: 0,
+		},
+		{
+			name:  "contract creation",
+			frame: rpc.CallFrame{Type: "CREATE", Input: confirmBatch},
+			calls: 0,
+		},
+		{
+			name: "multicall",
+			frame: rpc.CallFrame{Type: "CALL", To: &proxy, Input: []byte{0x01}, Calls: []rpc.CallFrame{
+				{Type: "CALL", To: &serviceManager, Input: confirmBatch},
+				{Type: "CALL", To: &serviceManager, Input: confirmBatch},
+			}},
+			calls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Len(t, findCalls(&tt.frame, serviceManager, selector), tt.calls)
+		})
+	}
+}
+
+func TestConfirmBatchCalldata(t *testing.T) {
+	serviceManagerContract, err := contracts.GetServiceManagerContract(config.AVSEnvEigenDAHolesky)
+	require.NoError(t, err)
+	selector := serviceManagerContract.Abi.Methods["confirmBatch"].ID
+	input := append(append([]byte{}, selector...), 0x01, 0x02)
+	multisig := common.HexToAddress("0x01")
+	tests := []struct {
+		name        string
+		to          common.Address
+		frame       *rpc.CallFrame
+		traceErr    error
+		data        []byte
+		undecodable bool
+	}{
+		{
+			name: "direct call",
+			to:   serviceManagerContract.Address,
+			data: []byte{0x01, 0x02},
+		},
+		{
+			name: "call through a multisig",
+			to:   multisig,
+			frame: &rpc.CallFrame{Type: "CALL", To: &multisig, Calls: []rpc.CallFrame{
+				{Type: "CALL", To: &serviceManagerContract.Address, Input: input},
+			}},
+			data: []byte{0x01, 0x02},
+		},
+		{
+			name:        "no confirmBatch call",
+			to:          multisig,
+			frame:       &rpc.CallFrame{Type: "CALL", To: &multisig},
+			undecodable: true,
+		},
+		{
+			name:        "endpoint without the debug namespace",
+			to:          multisig,
+			traceErr:    methodNotFoundError{},
+			undecodable: true,
+		},
+		{
+			name:     "trace failure",
+			to:       multisig,
+			traceErr: errors.New("timeout"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestExporter(t, &fakeRpc{traceTransaction: func(hash common.Hash) (*rpc.CallFrame, error) {
+				return tt.frame, tt.traceErr
+			}})
+			tx := types.NewTx(&types.LegacyTx{To: &tt.to, Data: input})
+
+			data, err := e.confirmBatchCalldata(tx)
+
+			if tt.data != nil {
+				require.NoError(t, err)
+				assert.Equal(t, tt.data, data)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.undecodable, errors.Is(err, errUndecodableBatch), "undecodable")
+		})
+	}
+}
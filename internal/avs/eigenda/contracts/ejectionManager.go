@@ -0,0 +1,38 @@
+package contracts
+
+import (
+	"bytes"
+	_ "embed"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// The ABI only has the parts of the EjectionManager used by the exporter,
+	// which are the same on every network.
+	//go:embed abi/ejection-manager.json
+	ejectionManagerABIBytes []byte
+	ejectionManagerABI      *abi.ABI
+)
+
+type EjectionManagerContract struct {
+	Address common.Address
+	Abi     abi.ABI
+}
+
+// NewEjectionManagerContract returns the EjectionManager at the given address.
+// Its address is not hardcoded as it is the ejector of the RegistryCoordinator.
+func NewEjectionManagerContract(address common.Address) (*EjectionManagerContract, error) {
+	if ejectionManagerABI == nil {
+		abi, err := abi.JSON(bytes.NewReader(ejectionManagerABIBytes))
+		if err != nil {
+			return nil, err
+		}
+		ejectionManagerABI = &abi
+	}
+	return &EjectionManagerContract{
+		Address: address,
+		Abi:     *ejectionManagerABI,
+	}, nil
+}
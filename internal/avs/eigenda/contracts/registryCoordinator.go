@@ -0,0 +1,38 @@
+package contracts
+
+import (
+	"bytes"
+	_ "embed"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// The ABI only has the parts of the RegistryCoordinator used by the
+	// exporter, which are the same on every network.
+	//go:embed abi/registry-coordinator.json
+	registryCoordinatorABIBytes []byte
+	registryCoordinatorABI      *abi.ABI
+)
+
+type RegistryCoordinatorContract struct {
+	Address common.Address
+	Abi     abi.ABI
+}
+
+// NewRegistryCoordinatorContract returns the RegistryCoordinator at the given
+// address. Its address is not hardcoded as it is read from the ServiceManager.
+func NewRegistryCoordinatorContract(address common.Address) (*RegistryCoordinatorContract, error) {
+	if registryCoordinatorABI == nil {
+		abi, err := abi.JSON(bytes.NewReader(registryCoordinatorABIBytes))
+		if err != nil {
+			return nil, err
+		}
+		registryCoordinatorABI = &abi
+	}
+	return &RegistryCoordinatorContract{
+		Address: address,
+		Abi:     *registryCoordinatorABI,
+	}, nil
+}
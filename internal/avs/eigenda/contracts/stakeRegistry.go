@@ -0,0 +1,38 @@
+package contracts
+
+import (
+	"bytes"
+	_ "embed"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// The ABI only has the parts of the StakeRegistry used by the exporter,
+	// which are the same on every network.
+	//go:embed abi/stake-registry.json
+	stakeRegistryABIBytes []byte
+	stakeRegistryABI      *abi.ABI
+)
+
+type StakeRegistryContract struct {
+	Address common.Address
+	Abi     abi.ABI
+}
+
+// NewStakeRegistryContract returns the StakeRegistry at the given address. Its
+// address is not hardcoded as it is read from the ServiceManager.
+func NewStakeRegistryContract(address common.Address) (*StakeRegistryContract, error) {
+	if stakeRegistryABI == nil {
+		abi, err := abi.JSON(bytes.NewReader(stakeRegistryABIBytes))
+		if err != nil {
+			return nil, err
+		}
+		stakeRegistryABI = &abi
+	}
+	return &StakeRegistryContract{
+		Address: address,
+		Abi:     *stakeRegistryABI,
+	}, nil
+}
@@ -1,8 +1,8 @@
 package eigenda
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/big"
@@ -13,6 +13,7 @@ import (
 
 	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda/contracts"
 	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avsexporter"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/checkpoint"
 	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
 	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/rpc"
 	"github.com/ethereum/go-ethereum"
@@ -20,11 +21,58 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
+// maxLogAttempts is the number of times a block range is processed because of
+// a failing log before the log is skipped.
+const maxLogAttempts = 5
+
 type eigenDAOnChainExporter struct {
 	avsEnv    string
 	network   string
-	operators []config.OperatorConfig
-	ethClient rpc.EthEvmRpc
+	operators []*trackedOperator
+	// operatorsByID indexes the tracked operators by operatorId
+	operatorsByID map[common.Hash]*trackedOperator
+	// unresolvedOperators are the operators whose BLS public key could not
+	// be resolved yet, which are not tracked
+	unresolvedOperators []config.OperatorConfig
+	// registryCoordinator and stakeRegistry are read from the ServiceManager
+	// at initialization
+	registryCoordinator *contracts.RegistryCoordinatorContract
+	stakeRegistry       *contracts.StakeRegistryContract
+	// ejectionManager is the ejector of the RegistryCoordinator
+	ejectionManager *contracts.EjectionManagerContract
+	// quorumTotalStakes is the total stake of each quorum
+	quorumTotalStakes map[uint8]*big.Int
+	// quorumThresholds is the percentage of stake required to confirm a batch,
+	// by quorum
+	quorumThresholds []byte
+	ethClient        rpc.EthEvmRpc
+	checkpoints      checkpoint.Store
+	journal          *blockJournal
+	startBlock       uint64
+	// blockTag and confirmations define the latest block the exporter processes
+	blockTag      string
+	confirmations uint64
+	pollInterval  time.Duration
+	pageSize      uint64
+	catchUp       bool
+	// txFetchConcurrency is the number of transactions fetched concurrently
+	txFetchConcurrency int
+	reconcileInterval  time.Duration
+	signingRateBatches int
+	signingRateWindows []time.Duration
+	// analytics is nil unless the network-wide non-signer analytics are
+	// enabled
+	analytics *networkAnalytics
+	// bitmaps caches the quorum bitmaps of the operators by reference block
+	bitmaps bitmapCache
+	// lastBatchID is the ID of the last processed batch, nil until a batch
+	// is processed
+	lastBatchID *uint32
+	// logAttempts is the number of failed attempts to process each log of the
+	// block range being processed
+	logAttempts map[logID]int
+	// pushMode is enabled when an RPC endpoint has a WebSocket URL
+	pushMode bool
 }
 
 func NewEigenDAOnChainExporter(avsEnv string, c *config.Config) (avsexporter.AVSExporter, error) {
@@ -49,19 +97,48 @@ func NewEigenDAOnChainExporter(avsEnv string, c *config.Config) (avsexporter.AVS
 		return nil, fmt.Errorf("invalid AVS environment: %s", avsEnv)
 	}
 	e := &eigenDAOnChainExporter{
-		avsEnv:    avsEnv,
-		network:   network,
-		operators: operators,
-	}
-	if err := e.init(c.RPCs); err != nil {
+		avsEnv:             avsEnv,
+		network:            network,
+		journal:            newBlockJournal(settleDepth(c.Networks[network].BlockTag, c.Networks[network].Confirmations)),
+		startBlock:         c.AVSEnvs[avsEnv].StartBlock,
+		blockTag:           c.Networks[network].BlockTag,
+		confirmations:      c.Networks[network].Confirmations,
+		pollInterval:       c.AVSEnvs[avsEnv].PollInterval,
+		pageSize:           c.AVSEnvs[avsEnv].PageSize,
+		catchUp:            c.AVSEnvs[avsEnv].CatchUp,
+		txFetchConcurrency: c.AVSEnvs[avsEnv].TxFetchConcurrency,
+		reconcileInterval:  c.AVSEnvs[avsEnv].ReconcileInterval,
+		signingRateBatches: c.AVSEnvs[avsEnv].SigningRate.Batches,
+		signingRateWindows: c.AVSEnvs[avsEnv].SigningRate.Windows,
+		analytics:          newNetworkAnalytics(c.AVSEnvs[avsEnv].NetworkAnalytics),
+		quorumTotalStakes:  make(map[uint8]*big.Int),
+	}
+	if e.pollInterval == 0 {
+		e.pollInterval = config.DefaultPollInterval
+	}
+	if e.pageSize == 0 {
+		e.pageSize = config.DefaultPageSize
+	}
+	if e.txFetchConcurrency == 0 {
+		e.txFetchConcurrency = config.DefaultTxFetchConcurrency
+	}
+	if e.reconcileInterval == 0 {
+		e.reconcileInterval = config.DefaultReconcileInterval
+	}
+	if e.signingRateBatches == 0 {
+		e.signingRateBatches = config.DefaultSigningRateBatches
+	}
+	if len(e.signingRateWindows) == 0 {
+		e.signingRateWindows = config.DefaultSigningRateWindows
+	}
+	if err := e.init(c.NetworkRPCs(network), c.Networks[network], operators); err != nil {
 		return nil, fmt.Errorf("failed to initialize exporter: %v", err)
 	}
-	// Initialize metricOnchainBatches metric to 0
-	for _, operator := range e.operators {
-		for _, status := range []string{"missed"} {
-			metricOnchainBatches.WithLabelValues(operator.Name, e.network, status).Add(0)
-		}
+	checkpoints, err := checkpoint.NewFileStore(c.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize checkpoint store: %v", err)
 	}
+	e.checkpoints = checkpoints
 	slog.Info("initialized exporter |", "avsEnv", e.avsEnv, "operators", len(e.operators))
 	return e, nil
 }
@@ -71,10 +148,224 @@ func (e *eigenDAOnChainExporter) Name() string {
 }
 
 func (e *eigenDAOnChainExporter) Run(ctx context.Context, c *config.Config) error {
-	// TODO: Add a configuration option for the ticker time
-	tickerTime := time.Second * 30
-	slog.Info("running exporter |", "avsEnv", e.avsEnv, "interval", tickerTime)
+	slog.Info("running exporter |", "avsEnv", e.avsEnv, "interval", e.pollInterval, "pageSize", e.pageSize, "catchUp", e.catchUp)
+
+	// Get the block to start from
+	latestBlock, err := e.getStartBlock()
+	if err != nil {
+		return err
+	}
+
+	// Overwrite the quorum status and stakes with the onchain state at the
+	// last processed block
+	e.reconcile(new(big.Int).Sub(latestBlock, big.NewInt(1)))
+
+	// Set exporter status to UP
+	metricExporterStatus.WithLabelValues(e.avsEnv).Set(1)
+
+	// In push mode, new heads and logs trigger the processing of the next block
+	// range as soon as they are received. The timer keeps polling in case the
+	// subscription drops, filling any gap with the next block range.
+	sub := e.trySubscribe(ctx)
+	defer func() { sub.unsubscribe() }()
+
+	timer := time.NewTimer(e.pollInterval)
+	defer timer.Stop()
+	reconcileTicker := time.NewTicker(e.reconcileInterval)
+	defer reconcileTicker.Stop()
+	processNext := func() {
+		var behind bool
+		latestBlock, behind, err = e.processNextBlockRange(latestBlock)
+		if err != nil {
+			slog.Error("exporter error |", "avsEnv", e.avsEnv, "error", err)
+		}
+		// In catch-up mode, keep processing pages back-to-back while the
+		// exporter is more than one page behind.
+		if e.catchUp && behind {
+			slog.Debug("catching up |", "avsEnv", e.avsEnv, "latestBlock", latestBlock)
+			timer.Reset(0)
+		} else {
+			timer.Reset(e.pollInterval)
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("exiting exporter |", "avsEnv", e.avsEnv)
+			return nil
+		case <-timer.C:
+			if e.pushMode && sub == nil {
+				sub = e.trySubscribe(ctx)
+			}
+			processNext()
+		case <-reconcileTicker.C:
+			e.reconcile(new(big.Int).Sub(latestBlock, big.NewInt(1)))
+		case header := <-sub.headsCh():
+			slog.Debug("new head received |", "avsEnv", e.avsEnv, "blockNumber", header.Number)
+			processNext()
+		case vLog := <-sub.logsCh():
+			slog.Debug("log received |", "avsEnv", e.avsEnv, "blockNumber", vLog.BlockNumber, "txHash", vLog.TxHash, "removed", vLog.Removed)
+			sub.drainLogs()
+			processNext()
+		case err := <-sub.headErr():
+			slog.Warn("new heads subscription dropped, falling back to polling |", "avsEnv", e.avsEnv, "error", err)
+			sub.unsubscribe()
+			sub = nil
+			metricExporterSubscribed.WithLabelValues(e.network).Set(0)
+		case err := <-sub.logErr():
+			slog.Warn("logs subscription dropped, falling back to polling |", "avsEnv", e.avsEnv, "error", err)
+			sub.unsubscribe()
+			sub = nil
+			metricExporterSubscribed.WithLabelValues(e.network).Set(0)
+		}
+	}
+}
+
+// reconcile compares the state derived from the logs with the onchain state at
+// the given processed block, overwriting the quorum status and stakes of the
+// operators.
+func (e *eigenDAOnChainExporter) reconcile(blockNumber *big.Int) {
+	e.resolveOperators()
+	// No block was processed yet
+	if blockNumber.Sign() < 0 {
+		return
+	}
+	if err := e.reconcileBatchID(blockNumber); err != nil {
+		slog.Error("failed to reconcile batch ID |", "avsEnv", e.avsEnv, "error", err)
+	}
+	if err := e.reconcileQuorums(blockNumber); err != nil {
+		slog.Error("failed to reconcile quorum status |", "avsEnv", e.avsEnv, "error", err)
+	}
+	if err := e.reconcileStakes(blockNumber); err != nil {
+		slog.Error("failed to reconcile operator stakes |", "avsEnv", e.avsEnv, "error", err)
+	}
+}
+
+// processNextBlockRange processes the next page of blocks from latestBlock and
+// returns the block to continue from and whether the exporter is still more
+// than one page behind the latest block. If a chain reorganization is
+// detected, the orphaned blocks are rolled back and processed again.
+func (e *eigenDAOnChainExporter) processNextBlockRange(latestBlock *big.Int) (*big.Int, bool, error) {
+	// Move the time windows of the signing rates and of the network
+	// analytics, even if no batch is confirmed
+	now := time.Now()
+	e.updateSigningRates(now)
+	e.updateAnalyticsWindow(now)
+
+	// Get the next block range
+	endBlock, err := e.getLatestBlock()
+	if err != nil {
+		return latestBlock, false, err
+	}
+	fromBlock, toBlock, err := e.nextBlockRange(latestBlock, endBlock)
+	if err != nil {
+		return latestBlock, false, err
+	}
+	if fromBlock == nil || toBlock == nil {
+		return latestBlock, false, nil
+	}
+	behind := new(big.Int).Sub(endBlock, toBlock).Cmp(new(big.Int).SetUint64(e.pageSize)) >= 0
+
+	// Check the range follows the processed blocks
+	resumeBlock, err := e.detectReorg(fromBlock)
+	if err != nil {
+		return latestBlock, false, err
+	}
+	if resumeBlock != nil {
+		return e.processNextBlockRange(resumeBlock)
+	}
+
+	// Get the last block header before the logs, so a reorg happening while the
+	// range is processed is detected on the next range.
+	toHeader, err := e.ethClient.HeaderByNumber(context.Background(), toBlock)
+	if err != nil {
+		return latestBlock, false, fmt.Errorf("failed to get header of block %d: %v", toBlock, err)
+	}
+	if err := e.processBlockRange(fromBlock, toBlock); err != nil {
+		return latestBlock, false, err
+	}
+	e.journal.recordBlock(toBlock.Uint64(), toHeader.Hash())
+	settledBlock, settledHash, settled := e.journal.settle(toBlock.Uint64())
+	e.journal.prune(toBlock.Uint64())
+
+	// Only the settled blocks are checkpointed, so the blocks whose counter
+	// increments are still pending are processed again after a restart
+	if settled {
+		if err := e.checkpoints.Save(e.avsEnv, checkpoint.Checkpoint{Block: settledBlock, BlockHash: settledHash.Hex()}); err != nil {
+			slog.Error("failed to save checkpoint |", "avsEnv", e.avsEnv, "block", settledBlock, "error", err)
+		}
+	}
+	return new(big.Int).Add(toBlock, big.NewInt(1)), behind, nil
+}
+
+func (e *eigenDAOnChainExporter) Backfill(ctx context.Context, c *config.Config, fromBlock uint64, toBlock uint64) error {
+	if fromBlock > toBlock {
+		return fmt.Errorf("invalid block range: from block %d is greater than to block %d", fromBlock, toBlock)
+	}
+	// Only process the blocks the exporter follows, as a reorg of the
+	// backfilled blocks is not detected
+	latestBlock, err := e.getLatestBlock()
+	if err != nil {
+		return err
+	}
+	if latestBlock.Cmp(new(big.Int).SetUint64(fromBlock)) < 0 {
+		return fmt.Errorf("invalid block range: from block %d is after the latest followed block %d", fromBlock, latestBlock)
+	}
+	if latestBlock.Cmp(new(big.Int).SetUint64(toBlock)) < 0 {
+		slog.Warn("to block is after the latest followed block, backfilling up to it |", "avsEnv", e.avsEnv, "toBlock", toBlock, "latestBlock", latestBlock, "blockTag", e.blockTag, "confirmations", e.confirmations)
+		toBlock = latestBlock.Uint64()
+	}
+	slog.Info("backfilling exporter |", "avsEnv", e.avsEnv, "fromBlock", fromBlock, "toBlock", toBlock)
+	if err := e.reconcileBackfill(fromBlock); err != nil {
+		return err
+	}
+
+	latestBlock = new(big.Int).SetUint64(fromBlock)
+	endBlock := new(big.Int).SetUint64(toBlock)
+	for latestBlock.Cmp(endBlock) <= 0 {
+		if ctx.Err() != nil {
+			slog.Info("exiting backfill |", "avsEnv", e.avsEnv, "latestBlock", latestBlock)
+			return nil
+		}
+		pageFromBlock, pageToBlock, err := e.nextBlockRange(latestBlock, endBlock)
+		if err != nil {
+			return err
+		}
+		if err := e.processBlockRange(pageFromBlock, pageToBlock); err != nil {
+			return err
+		}
+		// The backfilled blocks are not checked for reorganizations, so their
+		// counter increments are applied right away
+		e.journal.flush(pageToBlock.Uint64())
+		e.journal.prune(pageToBlock.Uint64())
+		latestBlock = new(big.Int).Add(pageToBlock, big.NewInt(1))
+	}
+	slog.Info("backfill completed |", "avsEnv", e.avsEnv, "fromBlock", fromBlock, "toBlock", toBlock)
+	return nil
+}
+
+// reconcileBackfill reads the quorum status and stakes of the operators at the
+// block before fromBlock, as the starting state of the backfill. Reading the
+// state of a past block requires an archive node, so the backfill fails
+// instead of processing the range from a wrong state.
+func (e *eigenDAOnChainExporter) reconcileBackfill(fromBlock uint64) error {
+	e.resolveOperators()
+	if fromBlock == 0 {
+		return nil
+	}
+	blockNumber := new(big.Int).SetUint64(fromBlock - 1)
+	if err := e.reconcileQuorums(blockNumber); err != nil {
+		return fmt.Errorf("failed to read the quorum status at block %d, backfilling requires an archive node: %v", blockNumber, err)
+	}
+	if err := e.reconcileStakes(blockNumber); err != nil {
+		return fmt.Errorf("failed to read the operator stakes at block %d, backfilling requires an archive node: %v", blockNumber, err)
+	}
+	return nil
+}
 
+// processBlockRange gets the logs of the given block range, both ends included,
+// and updates the metrics with them.
+func (e *eigenDAOnChainExporter) processBlockRange(fromBlock *big.Int, toBlock *big.Int) error {
 	// Load contracts
 	serviceManagerContract, err := contracts.GetServiceManagerContract(e.avsEnv)
 	if err != nil {
@@ -85,62 +376,75 @@ func (e *eigenDAOnChainExporter) Run(ctx context.Context, c *config.Config) erro
 		return err
 	}
 
-	// Get current block to start from
-	// TODO: Should we add a configuration option to start from a specific block?
-	latestBlock, err := e.getLatestBlock()
+	// Get logs from current block range
+	logs, err := e.getLogs(fromBlock, toBlock)
 	if err != nil {
 		return err
 	}
 
-	// Set exporter status to UP
-	metricExporterStatus.WithLabelValues(e.avsEnv).Set(1)
+	// Prefetch the transactions of the batches concurrently
+	txs, err := e.prefetchTransactions(rpc.WithPriority(context.Background(), rpc.PriorityLow), logs)
+	if err != nil {
+		return err
+	}
 
-	ticker := time.Tick(tickerTime)
-	for {
-		select {
-		case <-ctx.Done():
-			slog.Info("exiting exporter |", "avsEnv", e.avsEnv)
-			return nil
-		case <-ticker:
-			// Get the next block range
-			fromBlock, toBlock, err := e.nextBlockRange(latestBlock, tickerTime)
-			if err != nil {
-				slog.Error("exporter error |", "avsEnv", e.avsEnv, "error", err)
-				continue
-			}
-			if fromBlock == nil || toBlock == nil {
-				continue
-			}
-			// Get logs from current block range
-			logs, err := e.getLogs(fromBlock, toBlock)
-			if err != nil {
-				slog.Error("exporter error |", "avsEnv", e.avsEnv, "error", err)
-				continue
-			}
+	// Find the ejections first, as they are logged after the removals of the
+	// operators from the quorums
+	ejections, err := e.collectEjections(logs)
+	if err != nil {
+		return err
+	}
 
-			for _, vLog := range logs {
-				switch vLog.Topics[0].Hex() {
-				case serviceManagerContract.Abi.Events["BatchConfirmed"].ID.Hex():
-					if err := e.processBatchConfirmedLog(vLog); err != nil {
-						slog.Error("exporter error |", "avsEnv", e.avsEnv, "error", err)
-						continue
-					}
-				case blsApkRegistryContract.Abi.Events["OperatorRemovedFromQuorums"].ID.Hex():
-					if err := e.processOperatorRemovedFromQuorumsLog(vLog); err != nil {
-						slog.Error("exporter error |", "avsEnv", e.avsEnv, "error", err)
-						continue
-					}
-				case blsApkRegistryContract.Abi.Events["OperatorAddedToQuorums"].ID.Hex():
-					if err := e.processOperatorAddedToQuorumsLog(vLog); err != nil {
-						slog.Error("exporter error |", "avsEnv", e.avsEnv, "error", err)
-						continue
-					}
-				}
+	for _, vLog := range logs {
+		if vLog.Removed {
+			slog.Debug("skipping removed log |", "avsEnv", e.avsEnv, "blockNumber", vLog.BlockNumber, "txHash", vLog.TxHash)
+			continue
+		}
+		e.journal.recordBlock(vLog.BlockNumber, vLog.BlockHash)
+		var err error
+		switch vLog.Topics[0].Hex() {
+		case serviceManagerContract.Abi.Events["BatchConfirmed"].ID.Hex():
+			err = e.processBatchConfirmedLog(vLog, txs)
+		case blsApkRegistryContract.Abi.Events["OperatorRemovedFromQuorums"].ID.Hex():
+			err = e.processOperatorRemovedFromQuorumsLog(vLog, ejections)
+		case blsApkRegistryContract.Abi.Events["OperatorAddedToQuorums"].ID.Hex():
+			err = e.processOperatorAddedToQuorumsLog(vLog)
+		case e.stakeRegistry.Abi.Events["OperatorStakeUpdate"].ID.Hex():
+			err = e.processOperatorStakeUpdateLog(vLog)
+		case e.registryCoordinator.Abi.Events["OperatorDeregistered"].ID.Hex():
+			err = e.processOperatorDeregisteredLog(vLog, ejections)
+		}
+		if err != nil {
+			if e.retryLog(vLog) {
+				// Undo the logs of the range already processed, so the whole
+				// range is processed again and the checkpoint does not move
+				// past the failed log
+				e.journal.rollback(fromBlock.Uint64())
+				return fmt.Errorf("failed to process log of block %d in transaction %s: %v", vLog.BlockNumber, vLog.TxHash, err)
 			}
-			metricExporterLatestBlock.WithLabelValues(e.network).Set(float64(toBlock.Int64()))
-			latestBlock = new(big.Int).Add(toBlock, big.NewInt(1))
+			slog.Error("failed to process log, skipping it |", "avsEnv", e.avsEnv, "blockNumber", vLog.BlockNumber, "txHash", vLog.TxHash, "logIndex", vLog.Index, "attempts", maxLogAttempts, "error", err)
+			metricExporterSkippedLogs.WithLabelValues(e.network).Inc()
 		}
 	}
+	clear(e.logAttempts)
+	metricExporterLatestBlock.WithLabelValues(e.network).Set(float64(toBlock.Int64()))
+	return nil
+}
+
+// retryLog counts a failed attempt to process the log and reports whether its
+// block range must be processed again. After maxLogAttempts attempts the log
+// is skipped, so a log that cannot be processed does not stall the exporter.
+func (e *eigenDAOnChainExporter) retryLog(vLog types.Log) bool {
+	if e.logAttempts == nil {
+		e.logAttempts = make(map[logID]int)
+	}
+	id := newLogID(vLog)
+	e.logAttempts[id]++
+	if e.logAttempts[id] < maxLogAttempts {
+		return true
+	}
+	delete(e.logAttempts, id)
+	return false
 }
 
 func (e *eigenDAOnChainExporter) checkAVSEnv(avsEnv string) error {
@@ -150,15 +454,45 @@ func (e *eigenDAOnChainExporter) checkAVSEnv(avsEnv string) error {
 	return nil
 }
 
-func (e *eigenDAOnChainExporter) init(rpcs map[string]string) error {
+func (e *eigenDAOnChainExporter) checkBlockTag(blockTag string) error {
+	switch blockTag {
+	case "", config.BlockTagLatest:
+		return nil
+	case config.BlockTagSafe, config.BlockTagFinalized:
+		if e.confirmations != 0 {
+			return fmt.Errorf("confirmations are only supported with the %s block tag", config.BlockTagLatest)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid block tag: %s", blockTag)
+	}
+}
+
+func (e *eigenDAOnChainExporter) init(rpcs []config.RPCConfig, networkConfig config.NetworkConfig, operators []config.OperatorConfig) error {
 	if err := e.checkAVSEnv(e.avsEnv); err != nil {
 		return fmt.Errorf("failed to check AVS environment: %v", err)
 	}
 
-	if err := e.initRPC(rpcs); err != nil {
+	if err := e.checkBlockTag(e.blockTag); err != nil {
+		return fmt.Errorf("failed to check block tag: %v", err)
+	}
+
+	if err := e.initRPC(rpcs, networkConfig); err != nil {
 		return fmt.Errorf("failed to initialize RPC: %v", err)
 	}
 
+	if err := e.initRegistries(); err != nil {
+		return fmt.Errorf("failed to initialize registries: %v", err)
+	}
+
+	if err := e.initOperators(operators); err != nil {
+		return fmt.Errorf("failed to initialize operators: %v", err)
+	}
+
+	if err := e.initQuorumThresholds(); err != nil {
+		return fmt.Errorf("failed to initialize quorum confirmation thresholds: %v", err)
+	}
+
 	if err := e.initPrometheusMetrics(); err != nil {
 		return fmt.Errorf("failed to initialize prometheus metrics: %v", err)
 	}
@@ -169,81 +503,180 @@ func (e *eigenDAOnChainExporter) init(rpcs map[string]string) error {
 func (e *eigenDAOnChainExporter) initPrometheusMetrics() error {
 	for _, operator := range e.operators {
 		for quorum, in := range operator.EigenDAConfig.Quorums {
-			if in {
-				metricOnchainQuorumStatus.WithLabelValues(operator.Name, e.network, strconv.Itoa(quorum)).Set(1)
-			} else {
-				metricOnchainQuorumStatus.WithLabelValues(operator.Name, e.network, strconv.Itoa(quorum)).Set(0)
-			}
+			e.setQuorumStatus(operator, uint8(quorum), in)
 		}
 	}
 	return nil
 }
 
-func (e *eigenDAOnChainExporter) initRPC(rpcs map[string]string) error {
-	if rpcURL, ok := rpcs[e.network]; !ok {
+func (e *eigenDAOnChainExporter) initRPC(rpcs []config.RPCConfig, networkConfig config.NetworkConfig) error {
+	if len(rpcs) == 0 {
 		return fmt.Errorf("no RPC URL found for network: %s", e.network)
-	} else {
-		ethClient, err := rpc.NewEthEvmRpc(e.network, rpcURL, 3)
-		if err != nil {
-			return fmt.Errorf("failed to initialize RPC: %v", err)
+	}
+	endpoints := make([]rpc.Endpoint, 0, len(rpcs))
+	for _, rpcConfig := range rpcs {
+		name := rpcConfig.Name
+		if name == "" {
+			name = rpc.EndpointName(rpcConfig.URL)
+		}
+		endpoints = append(endpoints, rpc.Endpoint{
+			Name:     name,
+			URL:      rpcConfig.URL,
+			Priority: rpcConfig.Priority,
+			RPS:      rpcConfig.RPS,
+			Burst:    rpcConfig.Burst,
+		})
+		if rpc.IsWebSocketURL(rpcConfig.URL) {
+			e.pushMode = true
 		}
-		e.ethClient = ethClient
 	}
+	var opts []rpc.Option
+	if networkConfig.LogsConsensus.Enabled {
+		opts = append(opts, rpc.WithLogsConsensus(networkConfig.LogsConsensus.Endpoints, networkConfig.LogsConsensus.Quorum))
+	}
+	retryPolicy := rpc.RetryPolicy{
+		InitialInterval: networkConfig.Retry.InitialInterval,
+		Multiplier:      networkConfig.Retry.Multiplier,
+		MaxInterval:     networkConfig.Retry.MaxInterval,
+		MaxElapsedTime:  networkConfig.Retry.MaxElapsedTime,
+		MaxAttempts:     networkConfig.Retry.MaxAttempts,
+	}
+	ethClient, err := rpc.NewEthEvmRpc(e.network, endpoints, retryPolicy, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to initialize RPC: %v", err)
+	}
+	e.ethClient = ethClient
 	return nil
 }
 
+// getLatestBlock returns the latest block the exporter can process, according
+// to the block tag and confirmations of the network.
 func (e *eigenDAOnChainExporter) getLatestBlock() (*big.Int, error) {
-	blockNumber, err := e.ethClient.BlockNumber(context.Background())
+	// Head lookups are sent before the bulk requests of the exporters when the
+	// RPC endpoints are rate limited.
+	ctx := rpc.WithPriority(context.Background(), rpc.PriorityHigh)
+	switch e.blockTag {
+	case config.BlockTagSafe:
+		header, err := e.ethClient.SafeHeader(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the safe block: %v", err)
+		}
+		return header.Number, nil
+	case config.BlockTagFinalized:
+		header, err := e.ethClient.FinalizedHeader(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the finalized block: %v", err)
+		}
+		return header.Number, nil
+	}
+	blockNumber, err := e.ethClient.BlockNumber(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get the block number: %v", err)
 	}
-	return big.NewInt(int64(blockNumber)), nil
+	if blockNumber < e.confirmations {
+		return big.NewInt(0), nil
+	}
+	return new(big.Int).SetUint64(blockNumber - e.confirmations), nil
 }
 
-func (e *eigenDAOnChainExporter) nextBlockRange(latestBlock *big.Int, tickerTime time.Duration) (*big.Int, *big.Int, error) {
-	toBlock, err := e.getLatestBlock()
+// getStartBlock returns the first block the exporter should process. If a
+// checkpoint exists, the exporter resumes from the block after the last fully
+// processed one, replaying every block it missed while it was down. Otherwise it
+// starts from the configured start block or, if not set, from the latest block.
+func (e *eigenDAOnChainExporter) getStartBlock() (*big.Int, error) {
+	latestBlock, err := e.getLatestBlock()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+	lastCheckpoint, err := e.checkpoints.Load(e.avsEnv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint: %v", err)
+	}
+	if lastCheckpoint == nil && e.startBlock == 0 {
+		slog.Info("no checkpoint found, starting from latest block |", "avsEnv", e.avsEnv, "latestBlock", latestBlock)
+		metricExporterReplayedBlocks.WithLabelValues(e.network).Set(0)
+		return latestBlock, nil
+	}
+	startBlock := new(big.Int).SetUint64(e.startBlock)
+	if lastCheckpoint != nil {
+		startBlock.SetUint64(lastCheckpoint.Block + 1)
+		if lastCheckpoint.BlockHash != "" {
+			// Remember the checkpoint block to detect if it was reorganized out
+			// of the chain while the exporter was down.
+			e.journal.recordBlock(lastCheckpoint.Block, common.HexToHash(lastCheckpoint.BlockHash))
+		}
+		slog.Info("resuming from checkpoint |", "avsEnv", e.avsEnv, "checkpointBlock", lastCheckpoint.Block, "latestBlock", latestBlock)
+	} else {
+		slog.Info("no checkpoint found, starting from configured start block |", "avsEnv", e.avsEnv, "startBlock", startBlock, "latestBlock", latestBlock)
+	}
+	replayedBlocks := new(big.Int).Sub(latestBlock, startBlock)
+	replayedBlocks.Add(replayedBlocks, big.NewInt(1))
+	if replayedBlocks.Sign() < 0 {
+		replayedBlocks.SetInt64(0)
 	}
-	if latestBlock.Cmp(toBlock) >= 0 {
-		slog.Debug("latest RPC block is not greater than latest exporter block. Retrying after interval time |", "avsEnv", e.avsEnv, "rpcLatestBlock", toBlock, "exporterLatestBlock", latestBlock, "sleepTime", tickerTime)
+	metricExporterReplayedBlocks.WithLabelValues(e.network).Set(float64(replayedBlocks.Int64()))
+	return startBlock, nil
+}
+
+// nextBlockRange returns the next page of blocks to process starting from
+// latestBlock and ending at endBlock at most. It returns nil blocks if there
+// is nothing to process.
+func (e *eigenDAOnChainExporter) nextBlockRange(latestBlock *big.Int, endBlock *big.Int) (*big.Int, *big.Int, error) {
+	if latestBlock.Cmp(endBlock) > 0 {
+		slog.Debug("end block is not greater than latest exporter block. Retrying after interval time |", "avsEnv", e.avsEnv, "endBlock", endBlock, "exporterLatestBlock", latestBlock)
 		return nil, nil, nil
 	}
-	maxPaginationBlock := new(big.Int).Add(latestBlock, big.NewInt(1000))
+	toBlock := endBlock
+	maxPaginationBlock := new(big.Int).Add(latestBlock, new(big.Int).SetUint64(e.pageSize-1))
 	if toBlock.Cmp(maxPaginationBlock) > 0 {
-		slog.Debug("latest block is greater than max pagination block. Using max pagination block instead |", "avsEnv", e.avsEnv, "latestBlock", toBlock, "maxPaginationBlock", maxPaginationBlock, "diff", new(big.Int).Sub(toBlock, maxPaginationBlock))
+		slog.Debug("end block is greater than max pagination block. Using max pagination block instead |", "avsEnv", e.avsEnv, "endBlock", toBlock, "maxPaginationBlock", maxPaginationBlock, "diff", new(big.Int).Sub(toBlock, maxPaginationBlock))
 		toBlock = maxPaginationBlock
 	}
 	return latestBlock, toBlock, nil
 }
 
-func (e *eigenDAOnChainExporter) getLogs(fromBlock *big.Int, toBlock *big.Int) ([]types.Log, error) {
+// filterQuery returns the filter of the logs processed by the exporter, without
+// block range.
+func (e *eigenDAOnChainExporter) filterQuery() (ethereum.FilterQuery, error) {
 	// Load contracts
 	serviceManagerContract, err := contracts.GetServiceManagerContract(e.avsEnv)
 	if err != nil {
-		return nil, err
+		return ethereum.FilterQuery{}, err
 	}
 	blsApkRegistryContract, err := contracts.GetBlsApkRegistryContract(e.avsEnv)
 	if err != nil {
-		return nil, err
+		return ethereum.FilterQuery{}, err
 	}
 
-	// Build the filter query
-	query := ethereum.FilterQuery{
-		FromBlock: fromBlock,
-		ToBlock:   toBlock,
+	return ethereum.FilterQuery{
 		Addresses: []common.Address{
 			serviceManagerContract.Address,
 			blsApkRegistryContract.Address,
+			e.stakeRegistry.Address,
+			e.registryCoordinator.Address,
+			e.ejectionManager.Address,
 		},
 		Topics: [][]common.Hash{
 			{
 				serviceManagerContract.Abi.Events["BatchConfirmed"].ID,
 				blsApkRegistryContract.Abi.Events["OperatorAddedToQuorums"].ID,
 				blsApkRegistryContract.Abi.Events["OperatorRemovedFromQuorums"].ID,
+				e.stakeRegistry.Abi.Events["OperatorStakeUpdate"].ID,
+				e.registryCoordinator.Abi.Events["OperatorDeregistered"].ID,
+				e.ejectionManager.Abi.Events["OperatorEjected"].ID,
 			},
 		},
+	}, nil
+}
+
+func (e *eigenDAOnChainExporter) getLogs(fromBlock *big.Int, toBlock *big.Int) ([]types.Log, error) {
+	// Build the filter query
+	query, err := e.filterQuery()
+	if err != nil {
+		return nil, err
 	}
+	query.FromBlock = fromBlock
+	query.ToBlock = toBlock
 
 	// Get the logs
 	slog.Debug("filtering logs |", "avsEnv", e.avsEnv, "fromBlock", query.FromBlock, "toBlock", query.ToBlock)
@@ -252,10 +685,10 @@ func (e *eigenDAOnChainExporter) getLogs(fromBlock *big.Int, toBlock *big.Int) (
 		return nil, err
 	}
 
-	// Sort logs by block number and tx index
+	// Sort logs by block number and log index
 	sort.Slice(logs, func(i, j int) bool {
 		if logs[i].BlockNumber == logs[j].BlockNumber {
-			return logs[i].TxIndex < logs[j].TxIndex
+			return logs[i].Index < logs[j].Index
 		}
 		return logs[i].BlockNumber < logs[j].BlockNumber
 	})
@@ -263,59 +696,114 @@ func (e *eigenDAOnChainExporter) getLogs(fromBlock *big.Int, toBlock *big.Int) (
 	return logs, nil
 }
 
-func (e *eigenDAOnChainExporter) processBatchConfirmedLog(log types.Log) error {
-	// Load contracts
-	serviceManagerContract, err := contracts.GetServiceManagerContract(e.avsEnv)
+// processBatchConfirmedLog updates the batch metrics from a BatchConfirmed log.
+// The transaction of the log is taken from the prefetched transactions, or
+// fetched if it is not there.
+func (e *eigenDAOnChainExporter) processBatchConfirmedLog(log types.Log, txs map[common.Hash]*types.Transaction) error {
+	slog.Info("batch confirmed |", "avsEnv", e.avsEnv, "blockNumber", log.BlockNumber, "txHash", log.TxHash)
+	batchID, err := e.trackBatchID(log)
 	if err != nil {
 		return err
 	}
 
-	// Increase the number of batches counter
-	metricOnchainBatchesTotal.WithLabelValues(e.network).Inc()
-	slog.Info("batch confirmed |", "avsEnv", e.avsEnv, "blockNumber", log.BlockNumber, "txHash", log.TxHash)
-
 	// TODO: Ignoring the isPending output. Need to research more on this.
-	tx, _, err := e.ethClient.TransactionByHash(context.Background(), log.TxHash)
-	if err != nil {
-		return fmt.Errorf("failed to get transaction by hash: %v", err)
+	tx, ok := txs[log.TxHash]
+	if !ok {
+		var err error
+		tx, _, err = e.ethClient.TransactionByHash(rpc.WithPriority(context.Background(), rpc.PriorityLow), log.TxHash)
+		if err != nil {
+			return fmt.Errorf("failed to get transaction by hash: %v", err)
+		}
 	}
 
-	// Get the function signature (first 4 bytes of the input data)
-	funcSignature := tx.Data()[:4]
-	if !bytes.Equal(funcSignature, serviceManagerContract.Abi.Methods["confirmBatch"].ID) {
+	// Unpack the input data. Batches whose input cannot be decoded are only
+	// counted as undecodable, as their quorums are unknown.
+	data, err := e.confirmBatchCalldata(tx)
+	if errors.Is(err, errUndecodableBatch) {
+		e.undecodableBatch(log, err)
 		return nil
 	}
+	if err != nil {
+		return err
+	}
+	input, err := unpackConfirmBatchInput(e.avsEnv, data)
+	if err != nil {
+		e.undecodableBatch(log, err)
+		return nil
+	}
+	header := input.BatchHeader
+
+	// Look up the tracked operators among the non-signers by operatorId. The
+	// index of the quorum bitmap of each non-signer at the reference block is
+	// part of the input.
+	nonSignerBitmapIndices := make(map[*trackedOperator]uint32)
+	for i, pubkey := range input.NonSignerStakesAndSignature.NonSignerPubkeys {
+		if operator, ok := e.operatorsByID[pubkey.operatorID()]; ok {
+			nonSignerBitmapIndices[operator] = input.NonSignerStakesAndSignature.NonSignerQuorumBitmapIndices[i]
+		}
+	}
+	batchTime, err := e.blockTime(log.BlockNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get batch confirmation time: %v", err)
+	}
 
-	// Unpack the input data
-	input, err := unpackConfirmBatchInput(e.avsEnv, tx.Data()[4:])
+	// Get the quorums each operator was registered in at the reference block,
+	// as only those are attributed to it
+	bitmaps, err := e.quorumBitmaps(header.ReferenceBlockNumber, nonSignerBitmapIndices)
 	if err != nil {
-		return fmt.Errorf("failed to unpack confirmBatch input: %v", err)
+		return err
+	}
+
+	// Increase the number of batches counter of each quorum of the batch
+	for _, quorum := range header.QuorumNumbers {
+		e.journal.add(log.BlockNumber, metricOnchainBatchesTotal, metricOnchainBatchesRolledBack, e.network, strconv.Itoa(int(quorum)))
+	}
+	// The histograms are not observed again for a log processed again after a
+	// rollback, as their observations cannot be rolled back
+	replayed := !e.journal.observe(log)
+	e.recordSignedStake(header, replayed)
+	e.recordConfirmationLatency(log, header, batchTime, replayed)
+	// The analytics are optional, so they do not fail the batch
+	if err := e.recordNonSigners(log, input.NonSignerStakesAndSignature.NonSignerPubkeys, batchTime, replayed); err != nil {
+		slog.Error("failed to record network non-signers |", "avsEnv", e.avsEnv, "blockNumber", log.BlockNumber, "txHash", log.TxHash, "error", err)
 	}
 
-	// Iterate over the not signers and check if they are operators as not signers
 	for _, operator := range e.operators {
-		signed := true
-		for _, pubkey := range input.NonSignerStakesAndSignature.NonSignerPubkeys {
-			operatorBLSPubkeyX, operatorBLSPubkeyY, err := getOperatorBLSPubkey(operator)
-			if err != nil {
-				return fmt.Errorf("failed to get operator BLS public key: %v", err)
+		_, missed := nonSignerBitmapIndices[operator]
+		status := "signed"
+		if missed {
+			status = "missed"
+		}
+		attributed := false
+		for _, quorum := range header.QuorumNumbers {
+			if bitmaps[operator].Bit(int(quorum)) == 0 {
+				continue
 			}
-			if operatorBLSPubkeyX.Cmp(pubkey.X) == 0 || operatorBLSPubkeyY.Cmp(pubkey.Y) == 0 {
-				metricOnchainBatches.WithLabelValues(operator.Name, e.network, "missed").Inc()
-				slog.Info("operator failed to sign batch |", "avsEnv", e.avsEnv, "blockNumber", log.BlockNumber, "txIndex", log.TxIndex, "operator", operator.Name)
-				signed = false
+			attributed = true
+			e.journal.add(log.BlockNumber, metricOnchainBatches, metricOnchainOperatorBatchesRolledBack, operator.Name, e.network, strconv.Itoa(int(quorum)), status)
+			if missed {
+				slog.Info("operator failed to sign batch |", "avsEnv", e.avsEnv, "blockNumber", log.BlockNumber, "txIndex", log.TxIndex, "operator", operator.Name, "quorum", quorum)
+			} else {
+				slog.Info("operator signed batch |", "avsEnv", e.avsEnv, "blockNumber", log.BlockNumber, "txIndex", log.TxIndex, "operator", operator.Name, "quorum", quorum)
 			}
 		}
-		if signed {
-			metricOnchainBatches.WithLabelValues(operator.Name, e.network, "signed").Inc()
-			slog.Info("operator signed batch |", "avsEnv", e.avsEnv, "blockNumber", log.BlockNumber, "txIndex", log.TxIndex, "operator", operator.Name)
+		if attributed {
+			e.recordSigning(operator, !missed, log.BlockNumber, batchTime)
+			e.recordLastBatch(operator, status, log.BlockNumber, batchID, batchTime)
 		}
 	}
 
 	return nil
 }
 
-func (e *eigenDAOnChainExporter) processOperatorRemovedFromQuorumsLog(log types.Log) error {
+// undecodableBatch records a batch whose confirmBatch input could not be
+// decoded.
+func (e *eigenDAOnChainExporter) undecodableBatch(log types.Log, err error) {
+	slog.Warn("failed to decode confirmBatch input, skipping batch signers |", "avsEnv", e.avsEnv, "blockNumber", log.BlockNumber, "txHash", log.TxHash, "error", err)
+	e.journal.add(log.BlockNumber, metricOnchainUndecodableBatches, metricOnchainUndecodableBatchesRolledBack, e.network)
+}
+
+func (e *eigenDAOnChainExporter) processOperatorRemovedFromQuorumsLog(log types.Log, ejections ejections) error {
 	// Load contracts
 	blsApkRegistryContract, err := contracts.GetBlsApkRegistryContract(e.avsEnv)
 	if err != nil {
@@ -328,15 +816,18 @@ func (e *eigenDAOnChainExporter) processOperatorRemovedFromQuorumsLog(log types.
 	}
 	operatorAddress := logInputs[0].(common.Address)
 	quorumNumbers := logInputs[2].([]uint8)
-	operatorIndex := slices.IndexFunc(e.operators, func(operator config.OperatorConfig) bool {
+	operatorIndex := slices.IndexFunc(e.operators, func(operator *trackedOperator) bool {
 		return common.HexToAddress(operator.Address) == operatorAddress
 	})
 	if operatorIndex == -1 {
 		return nil
 	}
+	operator := e.operators[operatorIndex]
 	for _, quorum := range quorumNumbers {
-		slog.Info("operator removed from quorum |", "avsEnv", e.avsEnv, "blockNumber", log.BlockNumber, "txIndex", log.TxIndex, "operator", e.operators[operatorIndex].Name, "quorum", quorum)
-		metricOnchainQuorumStatus.WithLabelValues(e.operators[operatorIndex].Name, e.network, strconv.Itoa(int(quorum))).Set(0)
+		ejected := ejections.has(log.TxHash, operator.id, quorum)
+		slog.Info("operator removed from quorum |", "avsEnv", e.avsEnv, "blockNumber", log.BlockNumber, "txIndex", log.TxIndex, "operator", operator.Name, "quorum", quorum, "reason", removalReason(ejected))
+		e.setQuorumStatus(operator, quorum, false)
+		e.journal.add(log.BlockNumber, metricOperatorQuorumRemovals, metricOperatorQuorumRemovalsRolledBack, operator.Name, e.network, strconv.Itoa(int(quorum)), strconv.FormatBool(ejected))
 	}
 
 	return nil
@@ -355,7 +846,7 @@ func (e *eigenDAOnChainExporter) processOperatorAddedToQuorumsLog(log types.Log)
 	}
 	operatorAddress := logInputs[0].(common.Address)
 	quorumNumbers := logInputs[2].([]uint8)
-	operatorIndex := slices.IndexFunc(e.operators, func(operator config.OperatorConfig) bool {
+	operatorIndex := slices.IndexFunc(e.operators, func(operator *trackedOperator) bool {
 		return common.HexToAddress(operator.Address) == operatorAddress
 	})
 	if operatorIndex == -1 {
@@ -363,19 +854,7 @@ func (e *eigenDAOnChainExporter) processOperatorAddedToQuorumsLog(log types.Log)
 	}
 	for _, quorum := range quorumNumbers {
 		slog.Info("operator added to quorum |", "avsEnv", e.avsEnv, "blockNumber", log.BlockNumber, "txIndex", log.TxIndex, "operator", e.operators[operatorIndex].Name, "quorum", quorum)
-		metricOnchainQuorumStatus.WithLabelValues(e.operators[operatorIndex].Name, e.network, strconv.Itoa(int(quorum))).Set(1)
+		e.setQuorumStatus(e.operators[operatorIndex], quorum, true)
 	}
 	return nil
 }
-
-func getOperatorBLSPubkey(operator config.OperatorConfig) (*big.Int, *big.Int, error) {
-	x, ok := new(big.Int).SetString(operator.BLSPublicKey[0], 10)
-	if !ok {
-		return nil, nil, fmt.Errorf("failed to set string to big.Int: %s", operator.BLSPublicKey[0])
-	}
-	y, ok := new(big.Int).SetString(operator.BLSPublicKey[1], 10)
-	if !ok {
-		return nil, nil, fmt.Errorf("failed to set string to big.Int: %s", operator.BLSPublicKey[1])
-	}
-	return x, y, nil
-}
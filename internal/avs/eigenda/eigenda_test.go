@@ -0,0 +1,191 @@
+package eigenda
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/checkpoint"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessBlockRangeFailingLog(t *testing.T) {
+	operator := &trackedOperator{OperatorConfig: config.OperatorConfig{Name: "operator"}, id: common.HexToHash("0x0a")}
+	client := &fakeRpc{}
+	e := newTestExporter(t, client, operator)
+	// The stake update of the tracked operator cannot be unpacked
+	failingLog := types.Log{
+		BlockNumber: 11,
+		Topics:      []common.Hash{e.stakeRegistry.Abi.Events["OperatorStakeUpdate"].ID, operator.id},
+		TxHash:      common.HexToHash("0x01"),
+	}
+	client.filterLogs = func(query ethereum.FilterQuery) ([]types.Log, error) {
+		return []types.Log{failingLog}, nil
+	}
+	skipped := counterValue(t, metricExporterSkippedLogs.WithLabelValues(e.network))
+
+	// The range is processed again until the log is skipped
+	for attempt := 1; attempt < maxLogAttempts; attempt++ {
+		assert.Error(t, e.processBlockRange(big.NewInt(10), big.NewInt(20)), "attempt %d", attempt)
+	}
+	assert.Equal(t, skipped, counterValue(t, metricExporterSkippedLogs.WithLabelValues(e.network)))
+	assert.NoError(t, e.processBlockRange(big.NewInt(10), big.NewInt(20)))
+	assert.Equal(t, skipped+1, counterValue(t, metricExporterSkippedLogs.WithLabelValues(e.network)))
+	assert.Empty(t, e.logAttempts)
+}
+
+func TestReconcileBackfill(t *testing.T) {
+	tests := []struct {
+		name      string
+		fromBlock uint64
+		calls     int
+		err       bool
+	}{
+		{name: "from the genesis", fromBlock: 0, calls: 0},
+		{name: "state not available", fromBlock: 100, calls: 1, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The state at past blocks was pruned by the full node
+			calls := 0
+			e := newTestExporter(t, &fakeRpc{callContract: func(msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+				calls++
+				return nil, errors.New("missing trie node")
+			}})
+
+			err := e.reconcileBackfill(tt.fromBlock)
+
+			assert.Equal(t, tt.calls, calls)
+			if tt.err {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "archive node")
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGetLatestBlock(t *testing.T) {
+	header := func(number int64) func() (*types.Header, error) {
+		return func() (*types.Header, error) { return &types.Header{Number: big.NewInt(number)}, nil }
+	}
+	failing := func() (*types.Header, error) { return nil, errors.New("connection refused") }
+	tests := []struct {
+		name          string
+		blockTag      string
+		confirmations uint64
+		client        *fakeRpc
+		latestBlock   *big.Int
+	}{
+		{name: "latest block", client: &fakeRpc{blockNumber: func() (uint64, error) { return 100, nil }}, latestBlock: big.NewInt(100)},
+		{name: "confirmed block", blockTag: config.BlockTagLatest, confirmations: 12, client: &fakeRpc{blockNumber: func() (uint64, error) { return 100, nil }}, latestBlock: big.NewInt(88)},
+		{name: "fewer blocks than confirmations", confirmations: 12, client: &fakeRpc{blockNumber: func() (uint64, error) { return 5, nil }}, latestBlock: big.NewInt(0)},
+		{name: "safe block", blockTag: config.BlockTagSafe, client: &fakeRpc{safeHeader: header(90)}, latestBlock: big.NewInt(90)},
+		{name: "finalized block", blockTag: config.BlockTagFinalized, client: &fakeRpc{finalizedHeader: header(80)}, latestBlock: big.NewInt(80)},
+		{name: "block number not available", client: &fakeRpc{blockNumber: func() (uint64, error) { return 0, errors.New("connection refused") }}},
+		{name: "safe block not available", blockTag: config.BlockTagSafe, client: &fakeRpc{safeHeader: failing}},
+		{name: "finalized block not available", blockTag: config.BlockTagFinalized, client: &fakeRpc{finalizedHeader: failing}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestExporter(t, tt.client)
+			e.blockTag = tt.blockTag
+			e.confirmations = tt.confirmations
+
+			latestBlock, err := e.getLatestBlock()
+
+			if tt.latestBlock == nil {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.latestBlock, latestBlock)
+		})
+	}
+}
+
+func TestCheckBlockTag(t *testing.T) {
+	tests := []struct {
+		name          string
+		blockTag      string
+		confirmations uint64
+		err           bool
+	}{
+		{name: "default block tag", confirmations: 12},
+		{name: "latest block tag", blockTag: config.BlockTagLatest, confirmations: 12},
+		{name: "safe block tag", blockTag: config.BlockTagSafe},
+		{name: "finalized block tag", blockTag: config.BlockTagFinalized},
+		{name: "confirmations of the safe block", blockTag: config.BlockTagSafe, confirmations: 12, err: true},
+		{name: "confirmations of the finalized block", blockTag: config.BlockTagFinalized, confirmations: 12, err: true},
+		{name: "invalid block tag", blockTag: "pending", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestExporter(t, nil)
+			e.confirmations = tt.confirmations
+
+			err := e.checkBlockTag(tt.blockTag)
+
+			assert.Equal(t, tt.err, err != nil, "error: %v", err)
+		})
+	}
+}
+
+func TestProcessNextBlockRange(t *testing.T) {
+	chain := newChain(nil, 0, 3001, "a")
+	tests := []struct {
+		name        string
+		latestBlock int64
+		endBlock    uint64
+		endErr      error
+		pageSize    uint64
+		fromBlock   int64
+		toBlock     int64
+		nextBlock   int64
+		behind      bool
+		err         bool
+	}{
+		{name: "last page", latestBlock: 100, endBlock: 150, pageSize: 100, fromBlock: 100, toBlock: 150, nextBlock: 151},
+		{name: "page behind the latest block", latestBlock: 100, endBlock: 250, pageSize: 100, fromBlock: 100, toBlock: 199, nextBlock: 200},
+		{name: "more than one page behind", latestBlock: 100, endBlock: 3000, pageSize: 1000, fromBlock: 100, toBlock: 1099, nextBlock: 1100, behind: true},
+		{name: "no new block", latestBlock: 151, endBlock: 150, pageSize: 100, nextBlock: 151},
+		{name: "latest block not available", latestBlock: 100, endErr: errors.New("connection refused"), pageSize: 100, nextBlock: 100, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queries []ethereum.FilterQuery
+			e := newTestExporter(t, &fakeRpc{
+				blockNumber:    func() (uint64, error) { return tt.endBlock, tt.endErr },
+				headerByNumber: chainHeaders(chain),
+				filterLogs: func(query ethereum.FilterQuery) ([]types.Log, error) {
+					queries = append(queries, query)
+					return nil, nil
+				},
+			})
+			e.pageSize = tt.pageSize
+			checkpoints, err := checkpoint.NewFileStore(t.TempDir())
+			require.NoError(t, err)
+			e.checkpoints = checkpoints
+
+			nextBlock, behind, err := e.processNextBlockRange(big.NewInt(tt.latestBlock))
+
+			assert.Equal(t, tt.err, err != nil, "error: %v", err)
+			assert.Equal(t, big.NewInt(tt.nextBlock), nextBlock)
+			assert.Equal(t, tt.behind, behind)
+			if tt.toBlock == 0 {
+				assert.Empty(t, queries)
+				return
+			}
+			require.Len(t, queries, 1)
+			assert.Equal(t, big.NewInt(tt.fromBlock), queries[0].FromBlock)
+			assert.Equal(t, big.NewInt(tt.toBlock), queries[0].ToBlock)
+			assert.Equal(t, float64(tt.toBlock), gaugeValue(t, metricExporterLatestBlock.WithLabelValues(e.network)))
+		})
+	}
+}
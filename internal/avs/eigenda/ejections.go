@@ -0,0 +1,83 @@
+package eigenda
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ejection identifies the ejection of an operator from a quorum by a
+// transaction.
+type ejection struct {
+	txHash     common.Hash
+	operatorID common.Hash
+	quorum     uint8
+}
+
+// ejections are the ejections of a block range. As the EjectionManager logs an
+// ejection after the RegistryCoordinator removed the operator, the removals
+// are classified by looking for an ejection of the operator in the same
+// transaction.
+type ejections map[ejection]bool
+
+// has reports whether the operator was ejected from the quorum by the
+// transaction.
+func (ej ejections) has(txHash common.Hash, operatorID common.Hash, quorum uint8) bool {
+	return ej[ejection{txHash: txHash, operatorID: operatorID, quorum: quorum}]
+}
+
+// hasAny reports whether the operator was ejected from any quorum by the
+// transaction.
+func (ej ejections) hasAny(txHash common.Hash, operatorID common.Hash) bool {
+	for ejection := range ej {
+		if ejection.txHash == txHash && ejection.operatorID == operatorID {
+			return true
+		}
+	}
+	return false
+}
+
+// collectEjections returns the ejections of the OperatorEjected logs.
+func (e *eigenDAOnChainExporter) collectEjections(logs []types.Log) (ejections, error) {
+	ej := make(ejections)
+	for _, log := range logs {
+		if log.Removed || log.Address != e.ejectionManager.Address || log.Topics[0] != e.ejectionManager.Abi.Events["OperatorEjected"].ID {
+			continue
+		}
+		logInputs, err := e.ejectionManager.Abi.Events["OperatorEjected"].Inputs.Unpack(log.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack operator ejected log: %v", err)
+		}
+		operatorID := common.Hash(logInputs[0].([32]byte))
+		quorum := logInputs[1].(uint8)
+		if operator, ok := e.operatorsByID[operatorID]; ok {
+			slog.Warn("operator ejected from quorum |", "avsEnv", e.avsEnv, "blockNumber", log.BlockNumber, "txHash", log.TxHash, "operator", operator.Name, "quorum", quorum)
+		}
+		ej[ejection{txHash: log.TxHash, operatorID: operatorID, quorum: quorum}] = true
+	}
+	return ej, nil
+}
+
+// processOperatorDeregisteredLog counts the deregistration of a tracked
+// operator, which is ejected if the transaction ejected it from a quorum.
+func (e *eigenDAOnChainExporter) processOperatorDeregisteredLog(log types.Log, ejections ejections) error {
+	operator, ok := e.operatorsByID[common.Hash(log.Topics[2])]
+	if !ok {
+		return nil
+	}
+	ejected := ejections.hasAny(log.TxHash, operator.id)
+	slog.Info("operator deregistered |", "avsEnv", e.avsEnv, "blockNumber", log.BlockNumber, "txIndex", log.TxIndex, "operator", operator.Name, "reason", removalReason(ejected))
+	e.journal.add(log.BlockNumber, metricOperatorDeregistrations, metricOperatorDeregistrationsRolledBack, operator.Name, e.network, strconv.FormatBool(ejected))
+	return nil
+}
+
+// removalReason returns the reason of a removal in the logs.
+func removalReason(ejected bool) string {
+	if ejected {
+		return "ejected"
+	}
+	return "voluntary"
+}
@@ -0,0 +1,159 @@
+package eigenda
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda/contracts"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEjectedOperator returns an operator of the quorums 0 and 1.
+func newEjectedOperator() *trackedOperator {
+	return &trackedOperator{
+		OperatorConfig: config.OperatorConfig{Name: "operator", Address: "0x0000000000000000000000000000000000000001"},
+		id:             common.HexToHash("0x0a"),
+		quorums:        map[uint8]bool{0: true, 1: true},
+	}
+}
+
+func operatorEjectedLog(t *testing.T, e *eigenDAOnChainExporter, txHash common.Hash, operatorID common.Hash, quorum uint8) types.Log {
+	t.Helper()
+	event := e.ejectionManager.Abi.Events["OperatorEjected"]
+	data, err := event.Inputs.Pack(operatorID, quorum)
+	require.NoError(t, err)
+	return types.Log{Address: e.ejectionManager.Address, Topics: []common.Hash{event.ID}, Data: data, TxHash: txHash}
+}
+
+func TestProcessOperatorRemovedFromQuorumsLog(t *testing.T) {
+	blsApkRegistryContract, err := contracts.GetBlsApkRegistryContract(config.AVSEnvEigenDAHolesky)
+	require.NoError(t, err)
+	removalTx := common.HexToHash("0x01")
+	otherTx := common.HexToHash("0x02")
+	tests := []struct {
+		name     string
+		ejection func(e *eigenDAOnChainExporter, operator *trackedOperator) []types.Log
+		ejected  map[uint8]bool
+	}{
+		{
+			name: "voluntary removal",
+			ejection: func(e *eigenDAOnChainExporter, operator *trackedOperator) []types.Log {
+				return nil
+			},
+			ejected: map[uint8]bool{0: false, 1: false},
+		},
+		{
+			name: "ejection from every quorum",
+			ejection: func(e *eigenDAOnChainExporter, operator *trackedOperator) []types.Log {
+				return []types.Log{
+					operatorEjectedLog(t, e, removalTx, operator.id, 0),
+					operatorEjectedLog(t, e, removalTx, operator.id, 1),
+				}
+			},
+			ejected: map[uint8]bool{0: true, 1: true},
+		},
+		{
+			name: "ejection from one of the quorums",
+			ejection: func(e *eigenDAOnChainExporter, operator *trackedOperator) []types.Log {
+				return []types.Log{operatorEjectedLog(t, e, removalTx, operator.id, 1)}
+			},
+			ejected: map[uint8]bool{0: false, 1: true},
+		},
+		{
+			name: "ejection in another transaction",
+			ejection: func(e *eigenDAOnChainExporter, operator *trackedOperator) []types.Log {
+				return []types.Log{operatorEjectedLog(t, e, otherTx, operator.id, 0)}
+			},
+			ejected: map[uint8]bool{0: false, 1: false},
+		},
+		{
+			name: "ejection of another operator",
+			ejection: func(e *eigenDAOnChainExporter, operator *trackedOperator) []types.Log {
+				return []types.Log{operatorEjectedLog(t, e, removalTx, common.HexToHash("0x0b"), 0)}
+			},
+			ejected: map[uint8]bool{0: false, 1: false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operator := newEjectedOperator()
+			e := newTestExporter(t, nil, operator)
+			ejections, err := e.collectEjections(tt.ejection(e, operator))
+			require.NoError(t, err)
+			event := blsApkRegistryContract.Abi.Events["OperatorRemovedFromQuorums"]
+			data, err := event.Inputs.Pack(common.HexToAddress(operator.Address), [32]byte(operator.id), []byte{0, 1})
+			require.NoError(t, err)
+
+			// The metrics are global, so only their increase is checked
+			removals := func(quorum uint8, ejected bool) float64 {
+				return counterValue(t, metricOperatorQuorumRemovals.WithLabelValues(operator.Name, e.network, strconv.Itoa(int(quorum)), strconv.FormatBool(ejected)))
+			}
+			before := make(map[uint8]map[bool]float64)
+			for quorum := range tt.ejected {
+				before[quorum] = map[bool]float64{true: removals(quorum, true), false: removals(quorum, false)}
+			}
+
+			require.NoError(t, e.processOperatorRemovedFromQuorumsLog(types.Log{Topics: []common.Hash{event.ID}, Data: data, TxHash: removalTx}, ejections))
+			e.journal.flush(0)
+
+			for quorum, ejected := range tt.ejected {
+				assert.Equal(t, before[quorum][ejected]+1, removals(quorum, ejected), "quorum %d", quorum)
+				assert.Equal(t, before[quorum][!ejected], removals(quorum, !ejected), "quorum %d", quorum)
+				assert.False(t, operator.quorums[quorum])
+			}
+		})
+	}
+}
+
+func TestProcessOperatorDeregisteredLog(t *testing.T) {
+	deregistrationTx := common.HexToHash("0x01")
+	tests := []struct {
+		name      string
+		ejections ejections
+		ejected   bool
+	}{
+		{
+			name:      "voluntary deregistration",
+			ejections: ejections{},
+		},
+		{
+			name:      "ejection from a quorum",
+			ejections: ejections{{txHash: deregistrationTx, operatorID: common.HexToHash("0x0a"), quorum: 1}: true},
+			ejected:   true,
+		},
+		{
+			name:      "ejection in another transaction",
+			ejections: ejections{{txHash: common.HexToHash("0x02"), operatorID: common.HexToHash("0x0a"), quorum: 1}: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operator := newEjectedOperator()
+			e := newTestExporter(t, nil, operator)
+			log := types.Log{
+				Topics: []common.Hash{
+					e.registryCoordinator.Abi.Events["OperatorDeregistered"].ID,
+					common.BytesToHash(common.HexToAddress(operator.Address).Bytes()),
+					operator.id,
+				},
+				TxHash: deregistrationTx,
+			}
+
+			// The metrics are global, so only their increase is checked
+			deregistrations := func(ejected bool) float64 {
+				return counterValue(t, metricOperatorDeregistrations.WithLabelValues(operator.Name, e.network, strconv.FormatBool(ejected)))
+			}
+			classified, other := deregistrations(tt.ejected), deregistrations(!tt.ejected)
+
+			require.NoError(t, e.processOperatorDeregisteredLog(log, tt.ejections))
+			e.journal.flush(0)
+
+			assert.Equal(t, classified+1, deregistrations(tt.ejected))
+			assert.Equal(t, other, deregistrations(!tt.ejected))
+		})
+	}
+}
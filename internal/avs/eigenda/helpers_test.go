@@ -0,0 +1,157 @@
+package eigenda
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda/contracts"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/rpc"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRpc is an RPC client answering each method with its function. The
+// methods without a function panic.
+type fakeRpc struct {
+	rpc.EthEvmRpc
+	blockNumber       func() (uint64, error)
+	safeHeader        func() (*types.Header, error)
+	finalizedHeader   func() (*types.Header, error)
+	headerByNumber    func(number *big.Int) (*types.Header, error)
+	filterLogs        func(query ethereum.FilterQuery) ([]types.Log, error)
+	transactionByHash func(hash common.Hash) (*types.Transaction, bool, error)
+	traceTransaction  func(hash common.Hash) (*rpc.CallFrame, error)
+	callContract      func(msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	subscribeLogs     func(query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error)
+	subscribeHeads    func(ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
+func (f *fakeRpc) BlockNumber(ctx context.Context) (uint64, error) {
+	return f.blockNumber()
+}
+
+func (f *fakeRpc) SafeHeader(ctx context.Context) (*types.Header, error) {
+	return f.safeHeader()
+}
+
+func (f *fakeRpc) FinalizedHeader(ctx context.Context) (*types.Header, error) {
+	return f.finalizedHeader()
+}
+
+func (f *fakeRpc) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return f.headerByNumber(number)
+}
+
+func (f *fakeRpc) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	return f.filterLogs(query)
+}
+
+func (f *fakeRpc) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	return f.transactionByHash(hash)
+}
+
+func (f *fakeRpc) TraceTransaction(ctx context.Context, hash common.Hash) (*rpc.CallFrame, error) {
+	return f.traceTransaction(hash)
+}
+
+func (f *fakeRpc) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return f.callContract(msg, blockNumber)
+}
+
+func (f *fakeRpc) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return f.subscribeLogs(query, ch)
+}
+
+func (f *fakeRpc) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return f.subscribeHeads(ch)
+}
+
+// revertedError is the error of a reverted call.
+type revertedError struct{}
+
+func (revertedError) Error() string  { return "execution reverted" }
+func (revertedError) ErrorCode() int { return 3 }
+
+// methodNotFoundError is the error of an endpoint without the debug namespace.
+type methodNotFoundError struct{}
+
+func (methodNotFoundError) Error() string  { return "the method debug_traceTransaction does not exist" }
+func (methodNotFoundError) ErrorCode() int { return -32601 }
+
+// newTestExporter returns a Holesky exporter tracking the operators, whose
+// counter increments are applied once their block is flushed. The network is
+// named after the test, so the global metrics of each test are distinct.
+func newTestExporter(t *testing.T, client rpc.EthEvmRpc, operators ...*trackedOperator) *eigenDAOnChainExporter {
+	t.Helper()
+	registryCoordinator, err := contracts.NewRegistryCoordinatorContract(common.HexToAddress("0xc0"))
+	require.NoError(t, err)
+	stakeRegistry, err := contracts.NewStakeRegistryContract(common.HexToAddress("0x5e"))
+	require.NoError(t, err)
+	ejectionManager, err := contracts.NewEjectionManagerContract(common.HexToAddress("0xe1"))
+	require.NoError(t, err)
+	e := &eigenDAOnChainExporter{
+		avsEnv:              config.AVSEnvEigenDAHolesky,
+		network:             t.Name(),
+		operators:           operators,
+		operatorsByID:       make(map[common.Hash]*trackedOperator, len(operators)),
+		registryCoordinator: registryCoordinator,
+		stakeRegistry:       stakeRegistry,
+		ejectionManager:     ejectionManager,
+		quorumTotalStakes:   make(map[uint8]*big.Int),
+		ethClient:           client,
+		journal:             newBlockJournal(0),
+		txFetchConcurrency:  config.DefaultTxFetchConcurrency,
+		signingRateBatches:  3,
+		signingRateWindows:  []time.Duration{time.Hour, 24 * time.Hour},
+	}
+	for _, operator := range operators {
+		if operator.lastBatches == nil {
+			operator.lastBatches = make(map[string]lastBatch)
+		}
+		e.operatorsByID[operator.id] = operator
+	}
+	return e
+}
+
+// counterValue returns the value of a counter.
+func counterValue(t *testing.T, counter prometheus.Counter) float64 {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(counter))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	return families[0].GetMetric()[0].GetCounter().GetValue()
+}
+
+// gaugeValue returns the value of a gauge.
+func gaugeValue(t *testing.T, gauge prometheus.Gauge) float64 {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(gauge))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	return families[0].GetMetric()[0].GetGauge().GetValue()
+}
+
+// histogramCount returns the number of observations of a histogram.
+func histogramCount(t *testing.T, histogram prometheus.Histogram) uint64 {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(histogram))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	return families[0].GetMetric()[0].GetHistogram().GetSampleCount()
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
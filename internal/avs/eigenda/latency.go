@@ -0,0 +1,86 @@
+package eigenda
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// recordConfirmationLatency updates the latency metrics of a batch: the blocks
+// and seconds between its reference block and the block it was confirmed in,
+// at confirmationTime. The latencies are only observed the first time the
+// batch is processed.
+func (e *eigenDAOnChainExporter) recordConfirmationLatency(log types.Log, header batchHeader, confirmationTime time.Time, replayed bool) {
+	metricOnchainSecondsSinceLastBatch.set(e.network, confirmationTime)
+
+	referenceBlock := uint64(header.ReferenceBlockNumber)
+	if replayed || referenceBlock > log.BlockNumber {
+		return
+	}
+	metricOnchainBatchConfirmationBlocks.WithLabelValues(e.network).Observe(float64(log.BlockNumber - referenceBlock))
+	referenceTime, err := e.blockTime(referenceBlock)
+	if err != nil {
+		slog.Warn("failed to get batch reference block time |", "avsEnv", e.avsEnv, "referenceBlockNumber", referenceBlock, "error", err)
+		return
+	}
+	metricOnchainBatchConfirmationSeconds.WithLabelValues(e.network).Observe(confirmationTime.Sub(referenceTime).Seconds())
+}
+
+// blockTime returns the timestamp of the block.
+func (e *eigenDAOnChainExporter) blockTime(number uint64) (time.Time, error) {
+	header, err := e.ethClient.HeaderByNumber(context.Background(), new(big.Int).SetUint64(number))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get header of block %d: %v", number, err)
+	}
+	return time.Unix(int64(header.Time), 0), nil
+}
+
+// secondsSinceLastBatchCollector exports the seconds elapsed since the block
+// of the last confirmed batch of each network. It is computed when the
+// metrics are collected, so it keeps growing when no batch is confirmed.
+type secondsSinceLastBatchCollector struct {
+	desc  *prometheus.Desc
+	mu    sync.Mutex
+	times map[string]time.Time
+}
+
+func newSecondsSinceLastBatchCollector() *secondsSinceLastBatchCollector {
+	c := &secondsSinceLastBatchCollector{
+		desc: prometheus.NewDesc(
+			"eoe_eigenda_onchain_seconds_since_last_batch",
+			"Seconds since the block of the last confirmed eigenda onchain batch",
+			[]string{"network"}, nil,
+		),
+		times: make(map[string]time.Time),
+	}
+	prometheus.MustRegister(c)
+	return c
+}
+
+// set records the time of a confirmed batch, unless a later batch was already
+// recorded.
+func (c *secondsSinceLastBatchCollector) set(network string, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t.After(c.times[network]) {
+		c.times[network] = t
+	}
+}
+
+func (c *secondsSinceLastBatchCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *secondsSinceLastBatchCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for network, t := range c.times {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, time.Since(t).Seconds(), network)
+	}
+}
@@ -0,0 +1,89 @@
+package eigenda
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordConfirmationLatency(t *testing.T) {
+	confirmationTime := time.Unix(1_700_000_120, 0)
+	tests := []struct {
+		name           string
+		referenceBlock uint32
+		headerErr      error
+		replayed       bool
+		blocks         uint64
+		seconds        uint64
+	}{
+		{name: "first processing", referenceBlock: 90, blocks: 1, seconds: 1},
+		{name: "replayed batch", referenceBlock: 90, replayed: true},
+		{name: "reference block after the confirmation block", referenceBlock: 110},
+		{name: "reference block time not available", referenceBlock: 90, headerErr: errors.New("connection refused"), blocks: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestExporter(t, &fakeRpc{headerByNumber: func(number *big.Int) (*types.Header, error) {
+				if tt.headerErr != nil {
+					return nil, tt.headerErr
+				}
+				return &types.Header{Number: number, Time: 1_700_000_000}, nil
+			}})
+			// The histograms are global, so only their observations since the
+			// start of the test are checked
+			blocks := func() uint64 {
+				return histogramCount(t, metricOnchainBatchConfirmationBlocks.WithLabelValues(e.network).(prometheus.Histogram))
+			}
+			seconds := func() uint64 {
+				return histogramCount(t, metricOnchainBatchConfirmationSeconds.WithLabelValues(e.network).(prometheus.Histogram))
+			}
+			blocksBefore, secondsBefore := blocks(), seconds()
+
+			e.recordConfirmationLatency(types.Log{BlockNumber: 100}, batchHeader{ReferenceBlockNumber: tt.referenceBlock}, confirmationTime, tt.replayed)
+
+			assert.Equal(t, blocksBefore+tt.blocks, blocks())
+			assert.Equal(t, secondsBefore+tt.seconds, seconds())
+			// The time of the last batch is recorded even for replayed batches
+			metricOnchainSecondsSinceLastBatch.mu.Lock()
+			defer metricOnchainSecondsSinceLastBatch.mu.Unlock()
+			assert.Equal(t, confirmationTime, metricOnchainSecondsSinceLastBatch.times[e.network])
+		})
+	}
+}
+
+func TestSecondsSinceLastBatchCollector(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		times   []time.Time
+		seconds float64
+	}{
+		{name: "single batch", times: []time.Time{now.Add(-time.Minute)}, seconds: 60},
+		{name: "later batch", times: []time.Time{now.Add(-time.Hour), now.Add(-time.Minute)}, seconds: 60},
+		{name: "earlier batch", times: []time.Time{now.Add(-time.Minute), now.Add(-time.Hour)}, seconds: 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &secondsSinceLastBatchCollector{desc: metricOnchainSecondsSinceLastBatch.desc, times: make(map[string]time.Time)}
+			registry := prometheus.NewRegistry()
+			require.NoError(t, registry.Register(c))
+			for _, batchTime := range tt.times {
+				c.set("test", batchTime)
+			}
+
+			families, err := registry.Gather()
+
+			require.NoError(t, err)
+			require.Len(t, families, 1)
+			require.Len(t, families[0].GetMetric(), 1)
+			// The seconds keep growing until the metrics are collected
+			assert.InDelta(t, tt.seconds, families[0].GetMetric()[0].GetGauge().GetValue(), 5)
+		})
+	}
+}
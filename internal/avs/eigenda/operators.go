@@ -0,0 +1,141 @@
+package eigenda
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda/contracts"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// trackedOperator is an operator tracked by the exporter, with its BLS public
+// key as registered in the BLSApkRegistry.
+type trackedOperator struct {
+	config.OperatorConfig
+	pubkey g1Point
+	// id is the operatorId, the hash of the BLS public key
+	id common.Hash
+	// quorums is the quorum status of the operator, from the configuration,
+	// the quorum events and the reconciliations with the RegistryCoordinator
+	quorums map[uint8]bool
+	signing signingHistory
+	// lastBatches are the last batches attributed to the operator, by status
+	lastBatches map[string]lastBatch
+}
+
+// initOperators resolves the BLS public keys of the operators. Operators
+// without a configured key use the key registered in the BLSApkRegistry. When
+// the configured key differs from the registered one, the mismatch is reported
+// and the registered key is used, as it is the key the non-signers are
+// identified by. Operators whose key cannot be resolved, for example because
+// they are not registered yet, are not tracked until resolveOperators resolves
+// their key.
+func (e *eigenDAOnChainExporter) initOperators(operators []config.OperatorConfig) error {
+	e.operators = make([]*trackedOperator, 0, len(operators))
+	e.operatorsByID = make(map[common.Hash]*trackedOperator, len(operators))
+	for _, operator := range operators {
+		configured, err := parseBLSPubkey(operator)
+		if err != nil {
+			return fmt.Errorf("invalid BLS public key of operator %s: %v", operator.Name, err)
+		}
+		registered, err := e.getRegisteredPubkey(common.HexToAddress(operator.Address))
+		if err != nil {
+			if configured == nil {
+				slog.Error("failed to resolve BLS public key, the operator is not tracked until it is resolved |", "avsEnv", e.avsEnv, "operator", operator.Name, "error", err)
+				metricOperatorUnresolved.WithLabelValues(operator.Name, e.network).Set(1)
+				e.unresolvedOperators = append(e.unresolvedOperators, operator)
+				continue
+			}
+			slog.Warn("failed to get registered BLS public key, using the configured one |", "avsEnv", e.avsEnv, "operator", operator.Name, "error", err)
+			e.trackOperator(operator, *configured)
+			continue
+		}
+
+		if configured == nil {
+			slog.Info("resolved operator BLS public key |", "avsEnv", e.avsEnv, "operator", operator.Name, "X", registered.X, "Y", registered.Y)
+		} else if configured.X.Cmp(registered.X) != 0 || configured.Y.Cmp(registered.Y) != 0 {
+			slog.Error("configured BLS public key differs from the registered one, using the registered one |", "avsEnv", e.avsEnv, "operator", operator.Name, "configuredX", configured.X, "configuredY", configured.Y, "registeredX", registered.X, "registeredY", registered.Y)
+			metricOperatorBLSPubkeyMismatch.WithLabelValues(operator.Name, e.network).Set(1)
+		} else {
+			metricOperatorBLSPubkeyMismatch.WithLabelValues(operator.Name, e.network).Set(0)
+		}
+		e.trackOperator(operator, *registered)
+	}
+	return nil
+}
+
+// resolveOperators tries again to resolve the BLS public keys of the operators
+// that could not be resolved, and starts tracking the resolved ones.
+func (e *eigenDAOnChainExporter) resolveOperators() {
+	var unresolved []config.OperatorConfig
+	for _, operator := range e.unresolvedOperators {
+		registered, err := e.getRegisteredPubkey(common.HexToAddress(operator.Address))
+		if err != nil {
+			slog.Warn("failed to resolve BLS public key |", "avsEnv", e.avsEnv, "operator", operator.Name, "error", err)
+			unresolved = append(unresolved, operator)
+			continue
+		}
+		slog.Info("resolved operator BLS public key |", "avsEnv", e.avsEnv, "operator", operator.Name, "X", registered.X, "Y", registered.Y)
+		metricOperatorUnresolved.WithLabelValues(operator.Name, e.network).Set(0)
+		e.trackOperator(operator, *registered)
+	}
+	e.unresolvedOperators = unresolved
+}
+
+// trackOperator starts tracking the operator with the given BLS public key.
+func (e *eigenDAOnChainExporter) trackOperator(operator config.OperatorConfig, pubkey g1Point) {
+	tracked := &trackedOperator{
+		OperatorConfig: operator,
+		pubkey:         pubkey,
+		id:             pubkey.operatorID(),
+		quorums:        make(map[uint8]bool),
+		lastBatches:    make(map[string]lastBatch),
+	}
+	e.operators = append(e.operators, tracked)
+	e.operatorsByID[tracked.id] = tracked
+	metricOperatorInfo.WithLabelValues(tracked.Name, e.network, tracked.id.Hex()).Set(1)
+	slog.Debug("tracking operator |", "avsEnv", e.avsEnv, "operator", tracked.Name, "operatorId", tracked.id)
+}
+
+// getRegisteredPubkey returns the BLS public key registered by the operator in
+// the BLSApkRegistry.
+func (e *eigenDAOnChainExporter) getRegisteredPubkey(operatorAddress common.Address) (*g1Point, error) {
+	blsApkRegistryContract, err := contracts.GetBlsApkRegistryContract(e.avsEnv)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := e.callContract(context.Background(), blsApkRegistryContract.Address, blsApkRegistryContract.Abi, nil, "getRegisteredPubkey", operatorAddress)
+	if err != nil {
+		return nil, err
+	}
+	pubkey := *abi.ConvertType(outputs[0], new(g1Point)).(*g1Point)
+	return &pubkey, nil
+}
+
+// operatorID returns the operatorId of the BLS public key: the keccak256 hash
+// of its coordinates, as computed by the BLSApkRegistry.
+func (p g1Point) operatorID() common.Hash {
+	return crypto.Keccak256Hash(common.LeftPadBytes(p.X.Bytes(), 32), common.LeftPadBytes(p.Y.Bytes(), 32))
+}
+
+// parseBLSPubkey returns the configured BLS public key of the operator, or nil
+// if it is not configured.
+func parseBLSPubkey(operator config.OperatorConfig) (*g1Point, error) {
+	if operator.BLSPublicKey[0] == "" && operator.BLSPublicKey[1] == "" {
+		return nil, nil
+	}
+	x, ok := new(big.Int).SetString(operator.BLSPublicKey[0], 10)
+	if !ok {
+		return nil, fmt.Errorf("failed to set string to big.Int: %s", operator.BLSPublicKey[0])
+	}
+	y, ok := new(big.Int).SetString(operator.BLSPublicKey[1], 10)
+	if !ok {
+		return nil, fmt.Errorf("failed to set string to big.Int: %s", operator.BLSPublicKey[1])
+	}
+	return &g1Point{X: x, Y: y}, nil
+}
@@ -0,0 +1,114 @@
+package eigenda
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda/contracts"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registeredPubkeys returns the CallContract function of an RPC client
+// answering the getRegisteredPubkey calls of the BLSApkRegistry with the
+// registered keys. The calls for operators without a registered key fail.
+func registeredPubkeys(t *testing.T, registered map[common.Address]g1Point) func(msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	blsApkRegistryContract, err := contracts.GetBlsApkRegistryContract(config.AVSEnvEigenDAHolesky)
+	require.NoError(t, err)
+	method := blsApkRegistryContract.Abi.Methods["getRegisteredPubkey"]
+	return func(msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+		args, err := method.Inputs.Unpack(msg.Data[4:])
+		require.NoError(t, err)
+		pubkey, ok := registered[args[0].(common.Address)]
+		if !ok {
+			return nil, errors.New("execution reverted: BLSApkRegistry.getRegisteredPubkey: operator is not registered")
+		}
+		return method.Outputs.Pack(pubkey, pubkey.operatorID())
+	}
+}
+
+func TestOperatorID(t *testing.T) {
+	tests := []struct {
+		name   string
+		pubkey g1Point
+		id     common.Hash
+	}{
+		{
+			// keccak256(abi.encodePacked(uint256(1), uint256(2)))
+			name:   "generator of G1",
+			pubkey: g1Point{X: big.NewInt(1), Y: big.NewInt(2)},
+			id:     common.HexToHash("0xe90b7bceb6e7df5418fb78d8ee546e97c83a08bbccc01a0644d599ccd2a7c2e0"),
+		},
+		{
+			// keccak256(abi.encodePacked(uint256(0), uint256(0)))
+			name:   "zero point",
+			pubkey: g1Point{X: big.NewInt(0), Y: big.NewInt(0)},
+			id:     common.HexToHash("0xad3228b676f7d3cd4284a5443f17f1962b36e491b30a40b2405849e597ba5fb5"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.id, tt.pubkey.operatorID())
+		})
+	}
+}
+
+func TestParseBLSPubkey(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    [2]string
+		pubkey *g1Point
+		err    bool
+	}{
+		{name: "not configured"},
+		{name: "configured", key: [2]string{"1", "2"}, pubkey: &g1Point{X: big.NewInt(1), Y: big.NewInt(2)}},
+		{name: "missing coordinate", key: [2]string{"1", ""}, err: true},
+		{name: "hexadecimal coordinate", key: [2]string{"0x1", "2"}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pubkey, err := parseBLSPubkey(config.OperatorConfig{Name: "operator", BLSPublicKey: tt.key})
+
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.pubkey, pubkey)
+		})
+	}
+}
+
+func TestInitOperatorsUnresolved(t *testing.T) {
+	registered := common.HexToAddress("0x01")
+	unregistered := common.HexToAddress("0x02")
+	pubkeys := map[common.Address]g1Point{
+		registered: {X: big.NewInt(1), Y: big.NewInt(2)},
+	}
+	e := newTestExporter(t, &fakeRpc{callContract: registeredPubkeys(t, pubkeys)})
+
+	require.NoError(t, e.initOperators([]config.OperatorConfig{
+		{Name: "registered", Address: registered.Hex()},
+		{Name: "unregistered", Address: unregistered.Hex()},
+		{Name: "configured", Address: unregistered.Hex(), BLSPublicKey: [2]string{"3", "4"}},
+	}))
+	require.Len(t, e.operators, 2)
+	assert.Equal(t, "registered", e.operators[0].Name)
+	assert.Equal(t, "configured", e.operators[1].Name)
+	require.Len(t, e.unresolvedOperators, 1)
+	assert.Equal(t, "unregistered", e.unresolvedOperators[0].Name)
+
+	// The operator is tracked once it registers
+	pubkeys[unregistered] = g1Point{X: big.NewInt(5), Y: big.NewInt(6)}
+	e.resolveOperators()
+
+	assert.Empty(t, e.unresolvedOperators)
+	require.Len(t, e.operators, 3)
+	id := g1Point{X: big.NewInt(5), Y: big.NewInt(6)}.operatorID()
+	require.Contains(t, e.operatorsByID, id)
+	assert.Equal(t, "unregistered", e.operatorsByID[id].Name)
+}
@@ -0,0 +1,58 @@
+package eigenda
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda/contracts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// prefetchTransactions fetches the transactions of the BatchConfirmed logs
+// with a bounded pool of workers. Transactions that fail to be fetched are not
+// returned, so they are fetched again when their log is processed.
+func (e *eigenDAOnChainExporter) prefetchTransactions(ctx context.Context, logs []types.Log) (map[common.Hash]*types.Transaction, error) {
+	// Load contracts
+	serviceManagerContract, err := contracts.GetServiceManagerContract(e.avsEnv)
+	if err != nil {
+		return nil, err
+	}
+	batchConfirmedID := serviceManagerContract.Abi.Events["BatchConfirmed"].ID
+
+	hashes := make(map[common.Hash]bool)
+	hashCh := make(chan common.Hash, len(logs))
+	for _, vLog := range logs {
+		if vLog.Removed || len(vLog.Topics) == 0 || vLog.Topics[0] != batchConfirmedID || hashes[vLog.TxHash] {
+			continue
+		}
+		hashes[vLog.TxHash] = true
+		hashCh <- vLog.TxHash
+	}
+	close(hashCh)
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		txs = make(map[common.Hash]*types.Transaction, len(hashes))
+	)
+	for range min(e.txFetchConcurrency, len(hashes)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for hash := range hashCh {
+				tx, _, err := e.ethClient.TransactionByHash(ctx, hash)
+				if err != nil {
+					slog.Error("failed to prefetch transaction |", "avsEnv", e.avsEnv, "txHash", hash, "error", err)
+					continue
+				}
+				mu.Lock()
+				txs[hash] = tx
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return txs, nil
+}
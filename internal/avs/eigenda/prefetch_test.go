@@ -0,0 +1,80 @@
+package eigenda
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda/contracts"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// delayedTransactions returns the TransactionByHash function of an RPC client
+// answering after a fixed latency.
+func delayedTransactions(latency time.Duration) func(hash common.Hash) (*types.Transaction, bool, error) {
+	return func(hash common.Hash) (*types.Transaction, bool, error) {
+		time.Sleep(latency)
+		return types.NewTx(&types.LegacyTx{Nonce: new(big.Int).SetBytes(hash.Bytes()).Uint64()}), false, nil
+	}
+}
+
+func batchConfirmedLogs(tb testing.TB, n int) []types.Log {
+	tb.Helper()
+	serviceManagerContract, err := contracts.GetServiceManagerContract(config.AVSEnvEigenDAHolesky)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	logs := make([]types.Log, n)
+	for i := range logs {
+		logs[i] = types.Log{
+			Topics:      []common.Hash{serviceManagerContract.Abi.Events["BatchConfirmed"].ID},
+			TxHash:      common.BigToHash(big.NewInt(int64(i + 1))),
+			BlockNumber: uint64(i),
+		}
+	}
+	return logs
+}
+
+func TestPrefetchTransactions(t *testing.T) {
+	logs := batchConfirmedLogs(t, 20)
+	// Duplicated transactions are fetched once
+	logs = append(logs, logs[0])
+	e := newTestExporter(t, &fakeRpc{transactionByHash: delayedTransactions(0)})
+	e.txFetchConcurrency = 4
+
+	txs, err := e.prefetchTransactions(context.Background(), logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 20 {
+		t.Fatalf("expected 20 transactions, got %d", len(txs))
+	}
+	for _, vLog := range logs {
+		if txs[vLog.TxHash] == nil {
+			t.Fatalf("transaction %s not prefetched", vLog.TxHash)
+		}
+	}
+}
+
+func BenchmarkPrefetchTransactions(b *testing.B) {
+	logs := batchConfirmedLogs(b, 100)
+	for _, concurrency := range []int{1, 8, 32} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			e := &eigenDAOnChainExporter{
+				avsEnv:             config.AVSEnvEigenDAHolesky,
+				ethClient:          &fakeRpc{transactionByHash: delayedTransactions(time.Millisecond)},
+				txFetchConcurrency: concurrency,
+			}
+			for range b.N {
+				if _, err := e.prefetchTransactions(context.Background(), logs); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.N*len(logs))/b.Elapsed().Seconds(), "txs/s")
+		})
+	}
+}
@@ -11,24 +11,246 @@ var (
 		Name:      "eigenda_exporter_latest_block",
 		Help:      "Latest block number that the exporter has processed",
 	}, []string{"network"})
+	metricExporterReplayedBlocks = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_exporter_replayed_blocks",
+		Help:      "Number of blocks replayed from the last checkpoint when the exporter started",
+	}, []string{"network"})
+	metricExporterSubscribed = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_exporter_subscribed",
+		Help:      "Whether the exporter is subscribed to new heads and logs (push mode)",
+	}, []string{"network"})
+	metricExporterSkippedLogs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_exporter_skipped_logs_total",
+		Help:      "Number of logs skipped after failing to be processed too many times",
+	}, []string{"network"})
+	metricExporterReorgs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_exporter_reorgs_total",
+		Help:      "Number of chain reorganizations detected by the exporter",
+	}, []string{"network"})
+	metricExporterReorgDepth = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_exporter_reorg_depth",
+		Help:      "Number of processed blocks orphaned by each chain reorganization",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 8),
+	}, []string{"network"})
+	// The increments of the batch counters are applied once their block is
+	// settled. The increments coming from blocks orphaned before are counted in
+	// their rolled back counters instead.
 	metricOnchainBatchesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "eoe",
 		Name:      "eigenda_onchain_batches_total",
 		Help:      "Total number of eigenda onchain batches",
-	}, []string{"network"})
+	}, []string{"network", "quorum"})
+	metricOnchainBatchesRolledBack = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_onchain_batches_rolled_back_total",
+		Help:      "Number of eigenda onchain batches from rolled back blocks, not counted in eigenda_onchain_batches_total",
+	}, []string{"network", "quorum"})
 	metricOnchainBatches = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "eoe",
 		Name:      "eigenda_onchain_batches",
 		Help:      "Number of eigenda onchain batches",
-	}, []string{"operator", "network", "status"})
+	}, []string{"operator", "network", "quorum", "status"})
+	metricOnchainOperatorBatchesRolledBack = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_onchain_operator_batches_rolled_back_total",
+		Help:      "Number of eigenda onchain batches from rolled back blocks, not counted in eigenda_onchain_batches",
+	}, []string{"operator", "network", "quorum", "status"})
+	metricOnchainUndecodableBatches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_onchain_undecodable_batches_total",
+		Help:      "Number of eigenda onchain batches whose confirmBatch input could not be decoded",
+	}, []string{"network"})
+	metricOnchainUndecodableBatchesRolledBack = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_onchain_undecodable_batches_rolled_back_total",
+		Help:      "Number of eigenda onchain batches from rolled back blocks, not counted in eigenda_onchain_undecodable_batches_total",
+	}, []string{"network"})
+	metricOnchainBatchSignedStake = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_onchain_batch_signed_stake_percentage",
+		Help:      "Percentage of the stake of the quorum that signed the last eigenda onchain batch",
+	}, []string{"network", "quorum"})
+	metricOnchainBatchSignedStakeDistribution = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_onchain_batch_signed_stake_percentage_distribution",
+		Help:      "Percentage of the stake of the quorum that signed each eigenda onchain batch",
+		Buckets:   prometheus.LinearBuckets(40, 5, 13),
+	}, []string{"network", "quorum"})
+	metricOnchainBatchSignedStakeMargin = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_onchain_batch_signed_stake_margin_percentage",
+		Help:      "Signed stake percentage of the last eigenda onchain batch minus the confirmation threshold of the quorum",
+	}, []string{"network", "quorum"})
+	metricOnchainBatchSignedStakeMarginDistribution = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_onchain_batch_signed_stake_margin_percentage_distribution",
+		Help:      "Signed stake percentage of each eigenda onchain batch minus the confirmation threshold of the quorum",
+		Buckets:   []float64{0, 5, 10, 15, 20, 25, 30, 40, 50},
+	}, []string{"network", "quorum"})
+	metricOnchainQuorumConfirmationThreshold = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_onchain_quorum_confirmation_threshold_percentage",
+		Help:      "Percentage of the stake of the quorum required to confirm an eigenda onchain batch",
+	}, []string{"network", "quorum"})
+	metricOnchainBatchConfirmationBlocks = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_onchain_batch_confirmation_latency_blocks",
+		Help:      "Number of blocks between the reference block of each eigenda onchain batch and its confirmation",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+	}, []string{"network"})
+	metricOnchainBatchConfirmationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_onchain_batch_confirmation_latency_seconds",
+		Help:      "Seconds between the reference block of each eigenda onchain batch and its confirmation",
+		Buckets:   prometheus.ExponentialBuckets(12, 2, 10),
+	}, []string{"network"})
+	metricOnchainLastBatchID = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_onchain_last_batch_id",
+		Help:      "ID of the last eigenda onchain batch processed by the exporter",
+	}, []string{"network"})
+	metricOnchainBatchIDGaps = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_onchain_batch_id_gaps_total",
+		Help:      "Number of times consecutive eigenda onchain batches processed by the exporter skipped batch IDs",
+	}, []string{"network"})
+	metricOnchainBatchIDGapsRolledBack = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_onchain_batch_id_gaps_rolled_back_total",
+		Help:      "Number of batch ID gaps from rolled back blocks, not counted in eigenda_onchain_batch_id_gaps_total",
+	}, []string{"network"})
+	metricOnchainUnseenBatches = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_onchain_unseen_batches",
+		Help:      "Number of eigenda onchain batches confirmed up to the last processed block but not processed by the exporter, at the last reconciliation",
+	}, []string{"network"})
+	metricNetworkBatchNonSigners = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_network_batch_non_signers",
+		Help:      "Number of operators that did not sign the last eigenda onchain batch",
+	}, []string{"network"})
+	metricNetworkBatchNonSignersDistribution = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_network_batch_non_signers_distribution",
+		Help:      "Number of operators that did not sign each eigenda onchain batch",
+		Buckets:   []float64{0, 1, 2, 5, 10, 20, 50, 100, 200},
+	}, []string{"network"})
+	metricNetworkUniqueNonSigners = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_network_unique_non_signers",
+		Help:      "Number of distinct operators that did not sign an eigenda onchain batch within the analytics window",
+	}, []string{"network"})
+	metricNetworkTopMissedBatches = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_network_top_missed_batches",
+		Help:      "Number of eigenda onchain batches missed within the analytics window by the operators that missed the most",
+	}, []string{"network", "operator"})
 	metricOnchainQuorumStatus = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "eoe",
 		Name:      "eigenda_onchain_quorum_status",
 		Help:      "Quorum status of eigenda onchain",
 	}, []string{"operator", "network", "quorum"})
+	metricOperatorMissedBatchesStreak = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_operator_missed_batches_streak",
+		Help:      "Number of consecutive eigenda onchain batches missed by the operator up to the last one",
+	}, []string{"operator", "network"})
+	metricOperatorLongestMissedBatchesStreak = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_operator_longest_missed_batches_streak",
+		Help:      "Longest number of consecutive eigenda onchain batches missed by the operator since the exporter started",
+	}, []string{"operator", "network"})
+	metricOperatorSigningRateBatches = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_operator_signing_rate_last_batches",
+		Help:      "Ratio of the last eigenda onchain batches of the operator it signed",
+	}, []string{"operator", "network"})
+	metricOperatorSigningRate = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_operator_signing_rate",
+		Help:      "Ratio of the eigenda onchain batches of the operator it signed within the time window",
+	}, []string{"operator", "network", "window"})
+	metricOperatorLastBatchBlock = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_operator_last_batch_block",
+		Help:      "Block number of the last eigenda onchain batch signed or missed by the operator",
+	}, []string{"operator", "network", "status"})
+	metricOperatorLastBatchID = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_operator_last_batch_id",
+		Help:      "Batch ID of the last eigenda onchain batch signed or missed by the operator",
+	}, []string{"operator", "network", "status"})
+	metricOperatorLastBatchTimestamp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_operator_last_batch_timestamp_seconds",
+		Help:      "Block timestamp of the last eigenda onchain batch signed or missed by the operator",
+	}, []string{"operator", "network", "status"})
+	metricOperatorInfo = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_operator_info",
+		Help:      "Information about the tracked operator, always 1",
+	}, []string{"operator", "network", "operatorId"})
+	metricOperatorBLSPubkeyMismatch = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_operator_bls_pubkey_mismatch",
+		Help:      "Whether the configured BLS public key of the operator differs from the key registered in the BLSApkRegistry",
+	}, []string{"operator", "network"})
+	metricOperatorUnresolved = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_operator_unresolved",
+		Help:      "Whether the BLS public key of the operator could not be resolved, so the operator is not tracked",
+	}, []string{"operator", "network"})
+	metricOnchainQuorumStatusDrift = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_onchain_quorum_status_drift_total",
+		Help:      "Number of times the quorum status of the operator differed from the RegistryCoordinator when reconciled",
+	}, []string{"operator", "network", "quorum"})
+	metricOperatorStake = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_operator_stake",
+		Help:      "Stake of the operator in the quorum, in units of 1e18",
+	}, []string{"operator", "network", "quorum"})
+	metricOperatorStakeShare = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_operator_stake_share",
+		Help:      "Share of the total stake of the quorum held by the operator, from 0 to 1",
+	}, []string{"operator", "network", "quorum"})
+	metricOnchainQuorumTotalStake = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_onchain_quorum_total_stake",
+		Help:      "Total stake of the quorum, in units of 1e18",
+	}, []string{"network", "quorum"})
+	metricOperatorQuorumRemovals = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_operator_quorum_removals_total",
+		Help:      "Number of times the operator was removed from the quorum, by ejection or not",
+	}, []string{"operator", "network", "quorum", "ejection"})
+	metricOperatorQuorumRemovalsRolledBack = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_operator_quorum_removals_rolled_back_total",
+		Help:      "Number of removals from rolled back blocks, not counted in eigenda_operator_quorum_removals_total",
+	}, []string{"operator", "network", "quorum", "ejection"})
+	metricOperatorDeregistrations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_operator_deregistrations_total",
+		Help:      "Number of times the operator was deregistered from every quorum, by ejection or not",
+	}, []string{"operator", "network", "ejection"})
+	metricOperatorDeregistrationsRolledBack = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "eigenda_operator_deregistrations_rolled_back_total",
+		Help:      "Number of deregistrations from rolled back blocks, not counted in eigenda_operator_deregistrations_total",
+	}, []string{"operator", "network", "ejection"})
 	metricExporterStatus = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "eoe",
 		Name:      "eigenda_exporter_up",
 		Help:      "Status of the exporter",
 	}, []string{"avsEnv"})
 )
+
+// metricOnchainSecondsSinceLastBatch is computed when the metrics are collected.
+var metricOnchainSecondsSinceLastBatch = newSecondsSinceLastBatchCollector()
@@ -0,0 +1,54 @@
+package eigenda
+
+import (
+	"context"
+	"log/slog"
+	"math/big"
+	"strconv"
+)
+
+// setQuorumStatus records whether the operator is in the quorum.
+func (e *eigenDAOnChainExporter) setQuorumStatus(operator *trackedOperator, quorum uint8, in bool) {
+	operator.quorums[quorum] = in
+	if in {
+		metricOnchainQuorumStatus.WithLabelValues(operator.Name, e.network, strconv.Itoa(int(quorum))).Set(1)
+	} else {
+		metricOnchainQuorumStatus.WithLabelValues(operator.Name, e.network, strconv.Itoa(int(quorum))).Set(0)
+	}
+}
+
+// reconcileQuorums reads the quorums of the operators from the
+// RegistryCoordinator at the given processed block and overwrites their quorum
+// status, initializing the batch counters of the quorums they are in. A status
+// differing from the one derived from the configuration and the quorum events
+// is reported as a drift.
+func (e *eigenDAOnChainExporter) reconcileQuorums(blockNumber *big.Int) error {
+	quorumCount, err := e.quorumCount(blockNumber)
+	if err != nil {
+		return err
+	}
+	for _, operator := range e.operators {
+		outputs, err := e.callContract(context.Background(), e.registryCoordinator.Address, e.registryCoordinator.Abi, blockNumber, "getCurrentQuorumBitmap", operator.id)
+		if err != nil {
+			return err
+		}
+		bitmap := outputs[0].(*big.Int)
+		for quorum := uint8(0); quorum < quorumCount; quorum++ {
+			in := bitmap.Bit(int(quorum)) == 1
+			if known, ok := operator.quorums[quorum]; ok && known != in {
+				slog.Warn("quorum status drift detected |", "avsEnv", e.avsEnv, "blockNumber", blockNumber, "operator", operator.Name, "quorum", quorum, "expected", known, "onchain", in)
+				metricOnchainQuorumStatusDrift.WithLabelValues(operator.Name, e.network, strconv.Itoa(int(quorum))).Inc()
+			}
+			e.setQuorumStatus(operator, quorum, in)
+			if in {
+				// Initialize the batches of the quorum, so that increase()
+				// sees the first batch
+				for _, status := range []string{"missed", "signed"} {
+					metricOnchainBatches.WithLabelValues(operator.Name, e.network, strconv.Itoa(int(quorum)), status).Add(0)
+				}
+			}
+		}
+	}
+	slog.Debug("reconciled quorum status |", "avsEnv", e.avsEnv, "blockNumber", blockNumber, "quorumCount", quorumCount)
+	return nil
+}
@@ -0,0 +1,95 @@
+package eigenda
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileQuorums(t *testing.T) {
+	tests := []struct {
+		name    string
+		known   map[uint8]bool
+		bitmap  int64
+		callErr error
+		quorums map[uint8]bool
+		drifts  map[uint8]float64
+		err     bool
+	}{
+		{name: "unknown status", known: map[uint8]bool{}, bitmap: 0b01, quorums: map[uint8]bool{0: true, 1: false}},
+		{name: "matching status", known: map[uint8]bool{0: true, 1: false}, bitmap: 0b01, quorums: map[uint8]bool{0: true, 1: false}},
+		{name: "operator added to a quorum", known: map[uint8]bool{0: true, 1: false}, bitmap: 0b11, quorums: map[uint8]bool{0: true, 1: true}, drifts: map[uint8]float64{1: 1}},
+		{name: "operator removed from every quorum", known: map[uint8]bool{0: true, 1: true}, bitmap: 0b00, quorums: map[uint8]bool{0: false, 1: false}, drifts: map[uint8]float64{0: 1, 1: 1}},
+		{name: "state not available", known: map[uint8]bool{0: true}, callErr: errors.New("missing trie node"), quorums: map[uint8]bool{0: true}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operator := &trackedOperator{OperatorConfig: config.OperatorConfig{Name: "operator"}, id: common.HexToHash("0x0a"), quorums: tt.known}
+			client := &fakeRpc{}
+			e := newTestExporter(t, client, operator)
+			client.callContract = func(msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+				if tt.callErr != nil {
+					return nil, tt.callErr
+				}
+				method, err := e.registryCoordinator.Abi.MethodById(msg.Data[:4])
+				require.NoError(t, err)
+				switch method.Name {
+				case "quorumCount":
+					return method.Outputs.Pack(uint8(2))
+				case "getCurrentQuorumBitmap":
+					return method.Outputs.Pack(big.NewInt(tt.bitmap))
+				}
+				t.Fatalf("unexpected call to %s", method.Name)
+				return nil, nil
+			}
+			// The drift counter is global, so only its increase is checked
+			drifts := func(quorum uint8) float64 {
+				return counterValue(t, metricOnchainQuorumStatusDrift.WithLabelValues(operator.Name, e.network, strconv.Itoa(int(quorum))))
+			}
+			driftsBefore := map[uint8]float64{0: drifts(0), 1: drifts(1)}
+
+			err := e.reconcileQuorums(big.NewInt(100))
+
+			assert.Equal(t, tt.err, err != nil, "error: %v", err)
+			assert.Equal(t, tt.quorums, operator.quorums)
+			// The batches of the quorums the operator is in are initialized
+			registry := prometheus.NewRegistry()
+			require.NoError(t, registry.Register(metricOnchainBatches))
+			families, err := registry.Gather()
+			require.NoError(t, err)
+			initialized := make(map[string]int)
+			for _, family := range families {
+				for _, metric := range family.GetMetric() {
+					labels := make(map[string]string)
+					for _, label := range metric.GetLabel() {
+						labels[label.GetName()] = label.GetValue()
+					}
+					if labels["network"] == e.network {
+						initialized[labels["quorum"]]++
+					}
+				}
+			}
+			for quorum := uint8(0); quorum < 2; quorum++ {
+				quorumLabel := strconv.Itoa(int(quorum))
+				assert.Equal(t, driftsBefore[quorum]+tt.drifts[quorum], drifts(quorum), "quorum %d", quorum)
+				if tt.err {
+					continue
+				}
+				var status float64
+				if tt.quorums[quorum] {
+					status = 1
+				}
+				assert.Equal(t, status, gaugeValue(t, metricOnchainQuorumStatus.WithLabelValues(operator.Name, e.network, quorumLabel)), "quorum %d", quorum)
+				assert.Equal(t, 2*int(status), initialized[quorumLabel], "quorum %d", quorum)
+			}
+		})
+	}
+}
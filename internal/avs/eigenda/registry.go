@@ -0,0 +1,103 @@
+package eigenda
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math/big"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda/contracts"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/rpc"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// initRegistries reads the addresses of the RegistryCoordinator and of the
+// StakeRegistry from the ServiceManager, and the address of the
+// EjectionManager from the RegistryCoordinator.
+func (e *eigenDAOnChainExporter) initRegistries() error {
+	serviceManagerContract, err := contracts.GetServiceManagerContract(e.avsEnv)
+	if err != nil {
+		return err
+	}
+	outputs, err := e.callContract(context.Background(), serviceManagerContract.Address, serviceManagerContract.Abi, nil, "registryCoordinator")
+	if err != nil {
+		return err
+	}
+	e.registryCoordinator, err = contracts.NewRegistryCoordinatorContract(outputs[0].(common.Address))
+	if err != nil {
+		return fmt.Errorf("failed to load registry coordinator contract: %v", err)
+	}
+	outputs, err = e.callContract(context.Background(), serviceManagerContract.Address, serviceManagerContract.Abi, nil, "stakeRegistry")
+	if err != nil {
+		return err
+	}
+	e.stakeRegistry, err = contracts.NewStakeRegistryContract(outputs[0].(common.Address))
+	if err != nil {
+		return fmt.Errorf("failed to load stake registry contract: %v", err)
+	}
+	outputs, err = e.callContract(context.Background(), e.registryCoordinator.Address, e.registryCoordinator.Abi, nil, "ejector")
+	if err != nil {
+		return err
+	}
+	e.ejectionManager, err = contracts.NewEjectionManagerContract(outputs[0].(common.Address))
+	if err != nil {
+		return fmt.Errorf("failed to load ejection manager contract: %v", err)
+	}
+	return nil
+}
+
+// quorumCount returns the number of quorums at the given block.
+func (e *eigenDAOnChainExporter) quorumCount(blockNumber *big.Int) (uint8, error) {
+	outputs, err := e.callContract(context.Background(), e.registryCoordinator.Address, e.registryCoordinator.Abi, blockNumber, "quorumCount")
+	if err != nil {
+		return 0, err
+	}
+	return outputs[0].(uint8), nil
+}
+
+// quorumBitmapIndices returns the indices of the quorum bitmaps of the
+// operators at the given block, in the history of each operator. The indices
+// of all the operators are read with a single call, which reverts when any of
+// them has no bitmap at the block, that is, when it was never registered
+// before the block. The operators are then read one by one, and those without
+// a bitmap are not returned.
+func (e *eigenDAOnChainExporter) quorumBitmapIndices(operators []*trackedOperator, blockNumber uint32) (map[*trackedOperator]uint32, error) {
+	operatorIDs := make([][32]byte, len(operators))
+	for i, operator := range operators {
+		operatorIDs[i] = operator.id
+	}
+	outputs, err := e.callContract(context.Background(), e.registryCoordinator.Address, e.registryCoordinator.Abi, nil, "getQuorumBitmapIndicesAtBlockNumber", blockNumber, operatorIDs)
+	if err != nil {
+		if !rpc.IsExecutionReverted(err) {
+			return nil, err
+		}
+		indices := make(map[*trackedOperator]uint32)
+		if len(operators) == 1 {
+			return indices, nil
+		}
+		for _, operator := range operators {
+			operatorIndices, err := e.quorumBitmapIndices([]*trackedOperator{operator}, blockNumber)
+			if err != nil {
+				return nil, err
+			}
+			maps.Copy(indices, operatorIndices)
+		}
+		return indices, nil
+	}
+	indices := make(map[*trackedOperator]uint32, len(operators))
+	for i, index := range outputs[0].([]uint32) {
+		indices[operators[i]] = index
+	}
+	return indices, nil
+}
+
+// quorumBitmapAtIndex returns the bitmap of the quorums the operator was
+// registered in at the given block, from the index of the bitmap in the
+// history of the operator.
+func (e *eigenDAOnChainExporter) quorumBitmapAtIndex(operatorID common.Hash, blockNumber uint32, index uint32) (*big.Int, error) {
+	outputs, err := e.callContract(context.Background(), e.registryCoordinator.Address, e.registryCoordinator.Abi, nil, "getQuorumBitmapAtBlockNumberByIndex", operatorID, blockNumber, new(big.Int).SetUint64(uint64(index)))
+	if err != nil {
+		return nil, err
+	}
+	return outputs[0].(*big.Int), nil
+}
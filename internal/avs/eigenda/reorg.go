@@ -0,0 +1,277 @@
+package eigenda
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"slices"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// reorgWindow is the number of recently processed blocks whose hashes and
+// changes are remembered to recover from chain reorganizations.
+const reorgWindow = 128
+
+// logID identifies a log of a given chain.
+type logID struct {
+	blockHash common.Hash
+	txHash    common.Hash
+	index     uint
+}
+
+func newLogID(log types.Log) logID {
+	return logID{blockHash: log.BlockHash, txHash: log.TxHash, index: log.Index}
+}
+
+// settleDepth returns the number of blocks after which a processed block is
+// settled and its counter increments are applied, so the increments from the
+// blocks a chain reorganization may still orphan are not exported. The safe and
+// finalized blocks are not expected to be reorganized.
+func settleDepth(blockTag string, confirmations uint64) uint64 {
+	if blockTag == config.BlockTagSafe || blockTag == config.BlockTagFinalized {
+		return 0
+	}
+	return reorgWindow - min(confirmations, reorgWindow)
+}
+
+// blockJournal remembers the hashes of the recently processed blocks and how
+// to undo the changes applied from their logs.
+type blockJournal struct {
+	hashes map[uint64]common.Hash
+	// undos are the functions undoing the changes applied from the logs of
+	// each block, in the order the changes were applied
+	undos map[uint64][]func()
+	// pending are the counter increments from the logs of each block, applied
+	// once the block is settled
+	pending map[uint64][]func()
+	// observed are the logs already observed by the histograms, by block
+	observed map[uint64]map[logID]bool
+	// settleDepth is the number of blocks after which a processed block is
+	// settled
+	settleDepth uint64
+}
+
+func newBlockJournal(settleDepth uint64) *blockJournal {
+	return &blockJournal{
+		hashes:      make(map[uint64]common.Hash),
+		undos:       make(map[uint64][]func()),
+		pending:     make(map[uint64][]func()),
+		observed:    make(map[uint64]map[logID]bool),
+		settleDepth: settleDepth,
+	}
+}
+
+// recordBlock remembers the hash of a processed block.
+func (j *blockJournal) recordBlock(number uint64, hash common.Hash) {
+	j.hashes[number] = hash
+}
+
+// add increments the counter with the given labels once the block is settled.
+// If the block is rolled back before, the increment is discarded and counted
+// in the rolled back counter with the same labels instead, so the counters
+// never count the batches of orphaned blocks and stay monotonic.
+func (j *blockJournal) add(number uint64, metric *prometheus.CounterVec, rolledBack *prometheus.CounterVec, labels ...string) {
+	settled := false
+	j.pending[number] = append(j.pending[number], func() {
+		settled = true
+		metric.WithLabelValues(labels...).Inc()
+	})
+	j.onRollback(number, func() {
+		// The increment of a settled block cannot be undone
+		if !settled {
+			rolledBack.WithLabelValues(labels...).Inc()
+		}
+	})
+}
+
+// onRollback registers a function undoing a change applied from the logs of
+// the block, run if the block is rolled back.
+func (j *blockJournal) onRollback(number uint64, undo func()) {
+	j.undos[number] = append(j.undos[number], undo)
+}
+
+// observe reports whether the log is observed for the first time, and
+// remembers it. Observations that cannot be undone, such as those of
+// histograms, are only made the first time, so they are not counted twice when
+// the block of the log is processed again after a rollback. The same log in a
+// reorganized block has another block hash, so it is observed again.
+func (j *blockJournal) observe(log types.Log) bool {
+	id := newLogID(log)
+	if j.observed[log.BlockNumber][id] {
+		return false
+	}
+	if j.observed[log.BlockNumber] == nil {
+		j.observed[log.BlockNumber] = make(map[logID]bool)
+	}
+	j.observed[log.BlockNumber][id] = true
+	return true
+}
+
+// blocks returns the numbers of the remembered blocks, from newest to oldest.
+func (j *blockJournal) blocks() []uint64 {
+	numbers := make([]uint64, 0, len(j.hashes))
+	for number := range j.hashes {
+		numbers = append(numbers, number)
+	}
+	slices.Sort(numbers)
+	slices.Reverse(numbers)
+	return numbers
+}
+
+// rollback undoes the changes applied from every block from the given one,
+// from the newest to the oldest change, discards their pending counter
+// increments and forgets those blocks.
+func (j *blockJournal) rollback(from uint64) {
+	var numbers []uint64
+	for number := range j.undos {
+		if number >= from {
+			numbers = append(numbers, number)
+		}
+	}
+	slices.Sort(numbers)
+	slices.Reverse(numbers)
+	for _, number := range numbers {
+		undos := j.undos[number]
+		for i := len(undos) - 1; i >= 0; i-- {
+			undos[i]()
+		}
+		delete(j.undos, number)
+	}
+	for number := range j.pending {
+		if number >= from {
+			delete(j.pending, number)
+		}
+	}
+	for number := range j.hashes {
+		if number >= from {
+			delete(j.hashes, number)
+		}
+	}
+}
+
+// settle applies the pending counter increments of the blocks that are at
+// least settleDepth blocks behind latestBlock, from the oldest block. It
+// returns the newest remembered block among them, as the block the exporter
+// can resume from without counting any increment twice, and false if there is
+// none.
+func (j *blockJournal) settle(latestBlock uint64) (uint64, common.Hash, bool) {
+	if latestBlock < j.settleDepth {
+		return 0, common.Hash{}, false
+	}
+	settledBlock := latestBlock - j.settleDepth
+	j.flush(settledBlock)
+	var (
+		number uint64
+		hash   common.Hash
+		found  bool
+	)
+	for n, h := range j.hashes {
+		if n <= settledBlock && (!found || n > number) {
+			number, hash, found = n, h, true
+		}
+	}
+	return number, hash, found
+}
+
+// flush applies the pending counter increments of the blocks up to the given
+// one, from the oldest block.
+func (j *blockJournal) flush(to uint64) {
+	var numbers []uint64
+	for number := range j.pending {
+		if number <= to {
+			numbers = append(numbers, number)
+		}
+	}
+	slices.Sort(numbers)
+	for _, number := range numbers {
+		for _, increment := range j.pending[number] {
+			increment()
+		}
+		delete(j.pending, number)
+	}
+}
+
+// prune forgets the blocks that are out of the reorg window of latestBlock.
+// Their pending counter increments were already applied, as settleDepth is
+// not greater than the reorg window.
+func (j *blockJournal) prune(latestBlock uint64) {
+	if latestBlock < reorgWindow {
+		return
+	}
+	for number := range j.hashes {
+		if number <= latestBlock-reorgWindow {
+			delete(j.hashes, number)
+		}
+	}
+	for number := range j.undos {
+		if number <= latestBlock-reorgWindow {
+			delete(j.undos, number)
+		}
+	}
+	for number := range j.observed {
+		if number <= latestBlock-reorgWindow {
+			delete(j.observed, number)
+		}
+	}
+}
+
+// detectReorg checks that the parent of fromBlock is the last processed block.
+// If it is not, it looks for the newest processed block still in the chain,
+// rolls back the changes applied from the orphaned blocks and returns the block
+// the exporter must resume from. It returns nil if there was no reorg.
+func (e *eigenDAOnChainExporter) detectReorg(fromBlock *big.Int) (*big.Int, error) {
+	if fromBlock.Sign() == 0 {
+		return nil, nil
+	}
+	lastBlock := fromBlock.Uint64() - 1
+	lastHash, ok := e.journal.hashes[lastBlock]
+	if !ok {
+		return nil, nil
+	}
+	header, err := e.ethClient.HeaderByNumber(context.Background(), fromBlock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get header of block %d: %v", fromBlock, err)
+	}
+	if header.ParentHash == lastHash {
+		return nil, nil
+	}
+
+	// Look for the common ancestor
+	var ancestor *uint64
+	for _, number := range e.journal.blocks() {
+		header, err := e.ethClient.HeaderByNumber(context.Background(), new(big.Int).SetUint64(number))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get header of block %d: %v", number, err)
+		}
+		if header.Hash() == e.journal.hashes[number] {
+			ancestor = &number
+			break
+		}
+	}
+	resumeBlock := uint64(0)
+	if ancestor != nil {
+		resumeBlock = *ancestor + 1
+		e.journal.rollback(resumeBlock)
+		e.bitmaps.rollback(resumeBlock)
+	} else {
+		// Every remembered block was orphaned, so roll back all of them and
+		// process the whole reorg window again.
+		slog.Error("no common ancestor found in the reorg window |", "avsEnv", e.avsEnv, "lastBlock", lastBlock, "reorgWindow", reorgWindow)
+		if lastBlock >= reorgWindow {
+			resumeBlock = lastBlock - reorgWindow + 1
+		}
+		e.journal.rollback(0)
+		e.bitmaps.rollback(0)
+	}
+	depth := lastBlock - resumeBlock + 1
+
+	slog.Warn("chain reorganization detected |", "avsEnv", e.avsEnv, "lastBlock", lastBlock, "resumeBlock", resumeBlock, "depth", depth)
+	metricExporterReorgs.WithLabelValues(e.network).Inc()
+	metricExporterReorgDepth.WithLabelValues(e.network).Observe(float64(depth))
+	return new(big.Int).SetUint64(resumeBlock), nil
+}
@@ -0,0 +1,216 @@
+package eigenda
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chainHeaders returns the HeaderByNumber function of an RPC client
+// answering from a fixed chain.
+func chainHeaders(headers []*types.Header) func(number *big.Int) (*types.Header, error) {
+	return func(number *big.Int) (*types.Header, error) {
+		if number.Uint64() >= uint64(len(headers)) {
+			return nil, fmt.Errorf("block %d not found", number)
+		}
+		return headers[number.Uint64()], nil
+	}
+}
+
+// newChain returns the headers of a chain of n blocks, sharing the blocks of
+// parent before forkBlock. The fork name makes the hashes of its blocks differ
+// from the parent ones.
+func newChain(parent []*types.Header, forkBlock int, n int, fork string) []*types.Header {
+	headers := make([]*types.Header, n)
+	copy(headers, parent[:min(forkBlock, len(parent))])
+	for i := min(forkBlock, len(parent)); i < n; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i)), Extra: []byte(fork)}
+		if i > 0 {
+			header.ParentHash = headers[i-1].Hash()
+		}
+		headers[i] = header
+	}
+	return headers
+}
+
+func newTestCounters() (*prometheus.CounterVec, *prometheus.CounterVec) {
+	metric := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total"}, []string{"label"})
+	rolledBack := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_rolled_back_total"}, []string{"label"})
+	return metric, rolledBack
+}
+
+func TestBlockJournalRollback(t *testing.T) {
+	metric, rolledBack := newTestCounters()
+	j := newBlockJournal(2)
+	var undone []uint64
+	for number := uint64(1); number <= 5; number++ {
+		j.recordBlock(number, newChain(nil, 0, 1, fmt.Sprint(number))[0].Hash())
+		j.add(number, metric, rolledBack, "a")
+		j.onRollback(number, func() { undone = append(undone, number) })
+	}
+	// The increments of the blocks 1 to 3 are applied
+	j.settle(5)
+
+	j.rollback(3)
+
+	assert.Equal(t, float64(3), counterValue(t, metric.WithLabelValues("a")), "settled increments are kept")
+	assert.Equal(t, float64(2), counterValue(t, rolledBack.WithLabelValues("a")), "pending increments are discarded")
+	assert.Equal(t, []uint64{5, 4, 3}, undone, "newest blocks are undone first")
+	assert.Equal(t, []uint64{2, 1}, j.blocks())
+	assert.Len(t, j.undos, 2)
+	assert.Empty(t, j.pending)
+
+	// The discarded increments are not applied later
+	j.flush(5)
+	assert.Equal(t, float64(3), counterValue(t, metric.WithLabelValues("a")))
+}
+
+func TestBlockJournalSettle(t *testing.T) {
+	tests := []struct {
+		name         string
+		settleDepth  uint64
+		latestBlock  uint64
+		settledBlock uint64
+		settled      bool
+		count        float64
+	}{
+		{name: "nothing settled", settleDepth: 10, latestBlock: 11, settled: false, count: 0},
+		{name: "some blocks settled", settleDepth: 10, latestBlock: 16, settledBlock: 6, settled: true, count: 3},
+		{name: "settled block without logs", settleDepth: 10, latestBlock: 17, settledBlock: 6, settled: true, count: 3},
+		{name: "no settle depth", settleDepth: 0, latestBlock: 10, settledBlock: 10, settled: true, count: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metric, rolledBack := newTestCounters()
+			j := newBlockJournal(tt.settleDepth)
+			// Only the even blocks have logs
+			for number := uint64(2); number <= 10; number += 2 {
+				j.recordBlock(number, newChain(nil, 0, 1, fmt.Sprint(number))[0].Hash())
+				j.add(number, metric, rolledBack, "a")
+			}
+
+			settledBlock, hash, settled := j.settle(tt.latestBlock)
+
+			assert.Equal(t, tt.settled, settled)
+			if tt.settled {
+				assert.Equal(t, tt.settledBlock, settledBlock)
+				assert.Equal(t, j.hashes[settledBlock], hash)
+			}
+			assert.Equal(t, tt.count, counterValue(t, metric.WithLabelValues("a")))
+		})
+	}
+}
+
+func TestSettleDepth(t *testing.T) {
+	tests := []struct {
+		blockTag      string
+		confirmations uint64
+		depth         uint64
+	}{
+		{blockTag: "", depth: reorgWindow},
+		{blockTag: config.BlockTagLatest, confirmations: 28, depth: 100},
+		{blockTag: config.BlockTagLatest, confirmations: 200, depth: 0},
+		{blockTag: config.BlockTagSafe, depth: 0},
+		{blockTag: config.BlockTagFinalized, depth: 0},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s+%d", tt.blockTag, tt.confirmations), func(t *testing.T) {
+			assert.Equal(t, tt.depth, settleDepth(tt.blockTag, tt.confirmations))
+		})
+	}
+}
+
+func TestBlockJournalPrune(t *testing.T) {
+	tests := []struct {
+		name        string
+		blocks      uint64
+		latestBlock uint64
+		remembered  int
+	}{
+		{name: "within window", blocks: 100, latestBlock: 100, remembered: 100},
+		{name: "out of window", blocks: 300, latestBlock: 300, remembered: reorgWindow},
+		{name: "latest block ahead", blocks: 300, latestBlock: 400, remembered: 28},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metric, rolledBack := newTestCounters()
+			j := newBlockJournal(reorgWindow)
+			for number := uint64(1); number <= tt.blocks; number++ {
+				j.recordBlock(number, newChain(nil, 0, 1, fmt.Sprint(number))[0].Hash())
+				j.add(number, metric, rolledBack, "a")
+				j.observe(types.Log{BlockNumber: number})
+			}
+
+			j.settle(tt.latestBlock)
+			j.prune(tt.latestBlock)
+
+			assert.Len(t, j.hashes, tt.remembered)
+			assert.Len(t, j.undos, tt.remembered)
+			assert.Len(t, j.observed, tt.remembered)
+			assert.Len(t, j.pending, tt.remembered)
+			for number := range j.hashes {
+				assert.Greater(t, number+reorgWindow, tt.latestBlock)
+			}
+		})
+	}
+}
+
+func TestBlockJournalObserve(t *testing.T) {
+	j := newBlockJournal(0)
+	log := types.Log{BlockNumber: 11, BlockHash: common.HexToHash("0x0b"), TxHash: common.HexToHash("0x01"), Index: 2}
+	assert.True(t, j.observe(log))
+	assert.False(t, j.observe(log))
+
+	// The log is processed again after its block is rolled back
+	j.rollback(11)
+	assert.False(t, j.observe(log))
+
+	// The other logs of the block, and the same log in a reorganized block,
+	// are observed
+	other := log
+	other.Index = 3
+	assert.True(t, j.observe(other))
+	reorged := log
+	reorged.BlockHash = common.HexToHash("0x0c")
+	assert.True(t, j.observe(reorged))
+}
+
+func TestDetectReorg(t *testing.T) {
+	processed := newChain(nil, 0, 20, "a")
+	tests := []struct {
+		name        string
+		chain       []*types.Header
+		resumeBlock *big.Int
+		rolledBack  float64
+	}{
+		{name: "no reorg", chain: newChain(processed, 20, 21, "a"), resumeBlock: nil},
+		{name: "reorg", chain: newChain(processed, 17, 21, "b"), resumeBlock: big.NewInt(17), rolledBack: 3},
+		{name: "no common ancestor", chain: newChain(processed, 0, 21, "b"), resumeBlock: big.NewInt(0), rolledBack: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metric, rolledBack := newTestCounters()
+			e := newTestExporter(t, &fakeRpc{headerByNumber: chainHeaders(tt.chain)})
+			// The last 10 processed blocks have a batch each
+			for number := uint64(10); number < 20; number++ {
+				e.journal.recordBlock(number, processed[number].Hash())
+				e.journal.add(number, metric, rolledBack, "a")
+			}
+
+			resumeBlock, err := e.detectReorg(big.NewInt(20))
+			require.NoError(t, err)
+			e.journal.flush(20)
+
+			assert.Equal(t, tt.resumeBlock, resumeBlock)
+			assert.Equal(t, 10-tt.rolledBack, counterValue(t, metric.WithLabelValues("a")))
+			assert.Equal(t, tt.rolledBack, counterValue(t, rolledBack.WithLabelValues("a")))
+		})
+	}
+}
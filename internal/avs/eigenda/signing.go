@@ -0,0 +1,168 @@
+package eigenda
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// signingHistory is the signing state of a tracked operator over the last
+// batches attributed to it.
+type signingHistory struct {
+	// streak is the number of consecutive batches missed up to the last one
+	streak        int
+	longestStreak int
+	// recent are the last batches of the operator, whether they were signed,
+	// from oldest to newest
+	recent []bool
+	// batches are the batches within the longest time window, from oldest to
+	// newest
+	batches []signingRecord
+}
+
+type signingRecord struct {
+	time   time.Time
+	signed bool
+}
+
+// clone returns a copy of the history that does not share its slices.
+func (h signingHistory) clone() signingHistory {
+	h.recent = slices.Clone(h.recent)
+	h.batches = slices.Clone(h.batches)
+	return h
+}
+
+// recordSigning updates the streaks and signing rates of the operator with a
+// batch attributed to it, confirmed at batchTime in the given block. The
+// previous history is restored if the block is rolled back.
+func (e *eigenDAOnChainExporter) recordSigning(operator *trackedOperator, signed bool, blockNumber uint64, batchTime time.Time) {
+	previous := operator.signing.clone()
+	e.journal.onRollback(blockNumber, func() {
+		operator.signing = previous
+		e.exportSigning(operator)
+		e.updateOperatorSigningRates(operator, time.Now())
+	})
+
+	h := &operator.signing
+	if signed {
+		h.streak = 0
+	} else {
+		h.streak++
+		h.longestStreak = max(h.longestStreak, h.streak)
+	}
+	h.recent = append(h.recent, signed)
+	if len(h.recent) > e.signingRateBatches {
+		h.recent = h.recent[len(h.recent)-e.signingRateBatches:]
+	}
+	h.batches = append(h.batches, signingRecord{time: batchTime, signed: signed})
+	e.exportSigning(operator)
+	e.updateOperatorSigningRates(operator, time.Now())
+}
+
+// exportSigning exports the streaks and the signing rate over the last batches
+// of the operator.
+func (e *eigenDAOnChainExporter) exportSigning(operator *trackedOperator) {
+	h := &operator.signing
+	metricOperatorMissedBatchesStreak.WithLabelValues(operator.Name, e.network).Set(float64(h.streak))
+	metricOperatorLongestMissedBatchesStreak.WithLabelValues(operator.Name, e.network).Set(float64(h.longestStreak))
+	metricOperatorSigningRateBatches.WithLabelValues(operator.Name, e.network).Set(signingRate(h.recent))
+}
+
+// updateSigningRates updates the signing rates of the operators over the time
+// windows ending now, so they keep moving when no batch is confirmed.
+func (e *eigenDAOnChainExporter) updateSigningRates(now time.Time) {
+	for _, operator := range e.operators {
+		e.updateOperatorSigningRates(operator, now)
+	}
+}
+
+// updateOperatorSigningRates forgets the batches of the operator out of the
+// longest time window ending now, and exports the signing rate over each
+// window. The rate of a window without batches is not exported.
+func (e *eigenDAOnChainExporter) updateOperatorSigningRates(operator *trackedOperator, now time.Time) {
+	h := &operator.signing
+	longestWindow := time.Duration(0)
+	for _, window := range e.signingRateWindows {
+		longestWindow = max(longestWindow, window)
+	}
+	expired := 0
+	for expired < len(h.batches) && now.Sub(h.batches[expired].time) > longestWindow {
+		expired++
+	}
+	h.batches = h.batches[expired:]
+	for _, window := range e.signingRateWindows {
+		var inWindow []bool
+		for _, record := range h.batches {
+			if now.Sub(record.time) <= window {
+				inWindow = append(inWindow, record.signed)
+			}
+		}
+		if len(inWindow) == 0 {
+			metricOperatorSigningRate.DeleteLabelValues(operator.Name, e.network, formatWindow(window))
+			continue
+		}
+		metricOperatorSigningRate.WithLabelValues(operator.Name, e.network, formatWindow(window)).Set(signingRate(inWindow))
+	}
+}
+
+// lastBatch is the last batch attributed to an operator with a status.
+type lastBatch struct {
+	blockNumber uint64
+	batchID     uint32
+	time        time.Time
+}
+
+// recordLastBatch records the last batch attributed to the operator with the
+// given status, so the last signed and missed batches can be found without
+// going through the logs. The previous last batch is restored if the block is
+// rolled back.
+func (e *eigenDAOnChainExporter) recordLastBatch(operator *trackedOperator, status string, blockNumber uint64, batchID uint32, batchTime time.Time) {
+	previous, ok := operator.lastBatches[status]
+	e.journal.onRollback(blockNumber, func() {
+		if !ok {
+			delete(operator.lastBatches, status)
+			metricOperatorLastBatchBlock.DeleteLabelValues(operator.Name, e.network, status)
+			metricOperatorLastBatchID.DeleteLabelValues(operator.Name, e.network, status)
+			metricOperatorLastBatchTimestamp.DeleteLabelValues(operator.Name, e.network, status)
+			return
+		}
+		e.setLastBatch(operator, status, previous)
+	})
+	e.setLastBatch(operator, status, lastBatch{blockNumber: blockNumber, batchID: batchID, time: batchTime})
+}
+
+// setLastBatch sets the last batch attributed to the operator with the given
+// status.
+func (e *eigenDAOnChainExporter) setLastBatch(operator *trackedOperator, status string, batch lastBatch) {
+	operator.lastBatches[status] = batch
+	metricOperatorLastBatchBlock.WithLabelValues(operator.Name, e.network, status).Set(float64(batch.blockNumber))
+	metricOperatorLastBatchID.WithLabelValues(operator.Name, e.network, status).Set(float64(batch.batchID))
+	metricOperatorLastBatchTimestamp.WithLabelValues(operator.Name, e.network, status).Set(float64(batch.time.Unix()))
+}
+
+// signingRate returns the ratio of signed batches.
+func signingRate(signed []bool) float64 {
+	if len(signed) == 0 {
+		return 0
+	}
+	count := 0
+	for _, s := range signed {
+		if s {
+			count++
+		}
+	}
+	return float64(count) / float64(len(signed))
+}
+
+// formatWindow formats a time window for the metric labels, without the zero
+// minutes and seconds: 1h instead of 1h0m0s.
+func formatWindow(window time.Duration) string {
+	s := window.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,125 @@
+package eigenda
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordSigning(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name          string
+		signed        []bool
+		rollback      uint64
+		streak        int
+		longestStreak int
+		recent        []bool
+	}{
+		{
+			name:          "streak",
+			signed:        []bool{false, false, true, false},
+			rollback:      100,
+			streak:        1,
+			longestStreak: 2,
+			recent:        []bool{false, true, false},
+		},
+		{
+			name:          "rollback of the last batches",
+			signed:        []bool{false, false, true, false},
+			rollback:      2,
+			streak:        2,
+			longestStreak: 2,
+			recent:        []bool{false, false},
+		},
+		{
+			name:          "rollback of a streak",
+			signed:        []bool{true, false, false, false},
+			rollback:      1,
+			streak:        0,
+			longestStreak: 0,
+			recent:        []bool{true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operator := &trackedOperator{OperatorConfig: config.OperatorConfig{Name: "operator"}}
+			e := newTestExporter(t, nil, operator)
+			for i, signed := range tt.signed {
+				e.recordSigning(operator, signed, uint64(i), now)
+			}
+
+			e.journal.rollback(tt.rollback)
+
+			assert.Equal(t, tt.streak, operator.signing.streak)
+			assert.Equal(t, tt.longestStreak, operator.signing.longestStreak)
+			assert.Equal(t, tt.recent, operator.signing.recent)
+		})
+	}
+}
+
+func TestUpdateSigningRates(t *testing.T) {
+	operator := &trackedOperator{OperatorConfig: config.OperatorConfig{Name: "operator"}}
+	e := newTestExporter(t, nil, operator)
+	now := time.Now()
+	e.recordSigning(operator, false, 1, now.Add(-2*time.Hour))
+	e.recordSigning(operator, true, 2, now.Add(-30*time.Minute))
+
+	e.updateSigningRates(now)
+	assert.Len(t, operator.signing.batches, 2)
+
+	// No batch is confirmed for a day: the batches leave the windows
+	e.updateSigningRates(now.Add(23 * time.Hour))
+	assert.Len(t, operator.signing.batches, 1)
+	e.updateSigningRates(now.Add(25 * time.Hour))
+	assert.Empty(t, operator.signing.batches)
+}
+
+func TestRecordLastBatch(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		rollback uint64
+		signed   *lastBatch
+		missed   *lastBatch
+	}{
+		{
+			name:     "no rollback",
+			rollback: 100,
+			signed:   &lastBatch{blockNumber: 12, batchID: 3, time: now},
+			missed:   &lastBatch{blockNumber: 11, batchID: 2, time: now},
+		},
+		{
+			name:     "rollback of the last signed batch",
+			rollback: 12,
+			signed:   &lastBatch{blockNumber: 10, batchID: 1, time: now},
+			missed:   &lastBatch{blockNumber: 11, batchID: 2, time: now},
+		},
+		{
+			name:     "rollback of every batch",
+			rollback: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operator := &trackedOperator{OperatorConfig: config.OperatorConfig{Name: "operator"}}
+			e := newTestExporter(t, nil, operator)
+			e.recordLastBatch(operator, "signed", 10, 1, now)
+			e.recordLastBatch(operator, "missed", 11, 2, now)
+			e.recordLastBatch(operator, "signed", 12, 3, now)
+
+			e.journal.rollback(tt.rollback)
+
+			for status, expected := range map[string]*lastBatch{"signed": tt.signed, "missed": tt.missed} {
+				batch, ok := operator.lastBatches[status]
+				if expected == nil {
+					assert.False(t, ok, status)
+					continue
+				}
+				assert.Equal(t, *expected, batch, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package eigenda
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda/contracts"
+)
+
+// initQuorumThresholds reads the percentage of stake required to confirm a
+// batch in each quorum from the ServiceManager.
+func (e *eigenDAOnChainExporter) initQuorumThresholds() error {
+	serviceManagerContract, err := contracts.GetServiceManagerContract(e.avsEnv)
+	if err != nil {
+		return err
+	}
+	outputs, err := e.callContract(context.Background(), serviceManagerContract.Address, serviceManagerContract.Abi, nil, "quorumConfirmationThresholdPercentages")
+	if err != nil {
+		return err
+	}
+	e.quorumThresholds = outputs[0].([]byte)
+	for quorum, threshold := range e.quorumThresholds {
+		metricOnchainQuorumConfirmationThreshold.WithLabelValues(e.network, strconv.Itoa(quorum)).Set(float64(threshold))
+	}
+	slog.Debug("quorum confirmation thresholds |", "avsEnv", e.avsEnv, "thresholds", e.quorumThresholds)
+	return nil
+}
+
+// recordSignedStake updates the signed stake metrics of each quorum of the
+// batch, and their margin over the confirmation threshold of the quorum. The
+// distributions are only observed the first time the batch is processed.
+func (e *eigenDAOnChainExporter) recordSignedStake(header batchHeader, replayed bool) {
+	for i, quorum := range header.QuorumNumbers {
+		if i >= len(header.SignedStakeForQuorums) {
+			break
+		}
+		signedStake := float64(header.SignedStakeForQuorums[i])
+		quorumLabel := strconv.Itoa(int(quorum))
+		metricOnchainBatchSignedStake.WithLabelValues(e.network, quorumLabel).Set(signedStake)
+		if !replayed {
+			metricOnchainBatchSignedStakeDistribution.WithLabelValues(e.network, quorumLabel).Observe(signedStake)
+		}
+		if int(quorum) >= len(e.quorumThresholds) {
+			continue
+		}
+		margin := signedStake - float64(e.quorumThresholds[quorum])
+		metricOnchainBatchSignedStakeMargin.WithLabelValues(e.network, quorumLabel).Set(margin)
+		if !replayed {
+			metricOnchainBatchSignedStakeMarginDistribution.WithLabelValues(e.network, quorumLabel).Observe(margin)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package eigenda
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda/contracts"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/ethereum/go-ethereum"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordSignedStake(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      batchHeader
+		thresholds  []byte
+		replayed    bool
+		signedStake map[string]float64
+		margin      map[string]float64
+		observed    uint64
+	}{
+		{
+			name:        "first processing",
+			header:      batchHeader{QuorumNumbers: []byte{0, 1}, SignedStakeForQuorums: []byte{80, 60}},
+			thresholds:  []byte{55, 55},
+			signedStake: map[string]float64{"0": 80, "1": 60},
+			margin:      map[string]float64{"0": 25, "1": 5},
+			observed:    1,
+		},
+		{
+			name:        "replayed batch",
+			header:      batchHeader{QuorumNumbers: []byte{0, 1}, SignedStakeForQuorums: []byte{80, 60}},
+			thresholds:  []byte{55, 55},
+			replayed:    true,
+			signedStake: map[string]float64{"0": 80, "1": 60},
+			margin:      map[string]float64{"0": 25, "1": 5},
+		},
+		{
+			name:        "stake below the threshold",
+			header:      batchHeader{QuorumNumbers: []byte{0}, SignedStakeForQuorums: []byte{50}},
+			thresholds:  []byte{55},
+			signedStake: map[string]float64{"0": 50},
+			margin:      map[string]float64{"0": -5},
+			observed:    1,
+		},
+		{
+			name:        "quorum without threshold",
+			header:      batchHeader{QuorumNumbers: []byte{0, 1}, SignedStakeForQuorums: []byte{80, 60}},
+			thresholds:  []byte{55},
+			signedStake: map[string]float64{"0": 80, "1": 60},
+			margin:      map[string]float64{"0": 25},
+			observed:    1,
+		},
+		{
+			name:        "missing signed stake",
+			header:      batchHeader{QuorumNumbers: []byte{0, 1}, SignedStakeForQuorums: []byte{80}},
+			thresholds:  []byte{55, 55},
+			signedStake: map[string]float64{"0": 80},
+			margin:      map[string]float64{"0": 25},
+			observed:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestExporter(t, nil)
+			e.quorumThresholds = tt.thresholds
+			// The distributions are global, so only their observations since
+			// the start of the test are checked
+			signedDistribution := func(quorum string) uint64 {
+				return histogramCount(t, metricOnchainBatchSignedStakeDistribution.WithLabelValues(e.network, quorum).(prometheus.Histogram))
+			}
+			marginDistribution := func(quorum string) uint64 {
+				return histogramCount(t, metricOnchainBatchSignedStakeMarginDistribution.WithLabelValues(e.network, quorum).(prometheus.Histogram))
+			}
+			signedBefore := map[string]uint64{"0": signedDistribution("0"), "1": signedDistribution("1")}
+			marginBefore := map[string]uint64{"0": marginDistribution("0"), "1": marginDistribution("1")}
+
+			e.recordSignedStake(tt.header, tt.replayed)
+
+			for _, quorum := range []string{"0", "1"} {
+				assert.Equal(t, tt.signedStake[quorum], gaugeValue(t, metricOnchainBatchSignedStake.WithLabelValues(e.network, quorum)), "quorum %s", quorum)
+				assert.Equal(t, tt.margin[quorum], gaugeValue(t, metricOnchainBatchSignedStakeMargin.WithLabelValues(e.network, quorum)), "quorum %s", quorum)
+				var signedObserved, marginObserved uint64
+				if _, ok := tt.signedStake[quorum]; ok {
+					signedObserved = tt.observed
+				}
+				if _, ok := tt.margin[quorum]; ok {
+					marginObserved = tt.observed
+				}
+				assert.Equal(t, signedBefore[quorum]+signedObserved, signedDistribution(quorum), "quorum %s", quorum)
+				assert.Equal(t, marginBefore[quorum]+marginObserved, marginDistribution(quorum), "quorum %s", quorum)
+			}
+		})
+	}
+}
+
+func TestInitQuorumThresholds(t *testing.T) {
+	serviceManagerContract, err := contracts.GetServiceManagerContract(config.AVSEnvEigenDAHolesky)
+	require.NoError(t, err)
+	method := serviceManagerContract.Abi.Methods["quorumConfirmationThresholdPercentages"]
+	tests := []struct {
+		name       string
+		thresholds []byte
+		err        error
+	}{
+		{name: "two quorums", thresholds: []byte{55, 60}},
+		{name: "call failure", err: errors.New("connection refused")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestExporter(t, &fakeRpc{callContract: func(msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				return method.Outputs.Pack(tt.thresholds)
+			}})
+
+			err := e.initQuorumThresholds()
+
+			if tt.err != nil {
+				assert.Error(t, err)
+				assert.Nil(t, e.quorumThresholds)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.thresholds, e.quorumThresholds)
+			for quorum, quorumLabel := range []string{"0", "1"} {
+				assert.Equal(t, float64(tt.thresholds[quorum]), gaugeValue(t, metricOnchainQuorumConfirmationThreshold.WithLabelValues(e.network, quorumLabel)))
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package eigenda
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// reconcileStakes reads the stakes of the operators and the total stake of
+// each quorum from the StakeRegistry at the given processed block.
+func (e *eigenDAOnChainExporter) reconcileStakes(blockNumber *big.Int) error {
+	quorumCount, err := e.quorumCount(blockNumber)
+	if err != nil {
+		return err
+	}
+	for quorum := uint8(0); quorum < quorumCount; quorum++ {
+		if err := e.updateQuorumTotalStake(quorum, blockNumber); err != nil {
+			return err
+		}
+		for _, operator := range e.operators {
+			outputs, err := e.callContract(context.Background(), e.stakeRegistry.Address, e.stakeRegistry.Abi, blockNumber, "getCurrentStake", operator.id, quorum)
+			if err != nil {
+				return err
+			}
+			e.setOperatorStake(operator, quorum, outputs[0].(*big.Int))
+		}
+	}
+	slog.Debug("reconciled operator stakes |", "avsEnv", e.avsEnv, "blockNumber", blockNumber, "quorumCount", quorumCount)
+	return nil
+}
+
+// processOperatorStakeUpdateLog updates the stake of a tracked operator from an
+// OperatorStakeUpdate log, and the total stake of the quorum at the latest
+// block, as the state of past blocks is only served by archive nodes. The stake
+// updates of the other operators only change the total stake, which is read at
+// the next reconciliation.
+func (e *eigenDAOnChainExporter) processOperatorStakeUpdateLog(log types.Log) error {
+	operator, ok := e.operatorsByID[common.Hash(log.Topics[1])]
+	if !ok {
+		return nil
+	}
+	logInputs, err := e.stakeRegistry.Abi.Events["OperatorStakeUpdate"].Inputs.NonIndexed().Unpack(log.Data)
+	if err != nil {
+		return fmt.Errorf("failed to unpack operator stake update log: %v", err)
+	}
+	quorum := logInputs[0].(uint8)
+	stake := logInputs[1].(*big.Int)
+	slog.Info("operator stake updated |", "avsEnv", e.avsEnv, "blockNumber", log.BlockNumber, "txIndex", log.TxIndex, "operator", operator.Name, "quorum", quorum, "stake", stake)
+
+	// The stake of the operator is known from the log, so a failure to refresh
+	// the total stake only leaves its share stale until the next
+	// reconciliation
+	if err := e.updateQuorumTotalStake(quorum, nil); err != nil {
+		slog.Warn("failed to update quorum total stake |", "avsEnv", e.avsEnv, "blockNumber", log.BlockNumber, "quorum", quorum, "error", err)
+	}
+	e.setOperatorStake(operator, quorum, stake)
+	return nil
+}
+
+// updateQuorumTotalStake reads the total stake of the quorum at the given
+// block, or at the latest block if it is nil.
+func (e *eigenDAOnChainExporter) updateQuorumTotalStake(quorum uint8, blockNumber *big.Int) error {
+	outputs, err := e.callContract(context.Background(), e.stakeRegistry.Address, e.stakeRegistry.Abi, blockNumber, "getCurrentTotalStake", quorum)
+	if err != nil {
+		return err
+	}
+	totalStake := outputs[0].(*big.Int)
+	e.quorumTotalStakes[quorum] = totalStake
+	metricOnchainQuorumTotalStake.WithLabelValues(e.network, strconv.Itoa(int(quorum))).Set(stakeToFloat(totalStake))
+	return nil
+}
+
+// setOperatorStake updates the stake of the operator in the quorum and its
+// share of the total stake of the quorum.
+func (e *eigenDAOnChainExporter) setOperatorStake(operator *trackedOperator, quorum uint8, stake *big.Int) {
+	quorumLabel := strconv.Itoa(int(quorum))
+	metricOperatorStake.WithLabelValues(operator.Name, e.network, quorumLabel).Set(stakeToFloat(stake))
+	share := 0.0
+	if totalStake := e.quorumTotalStakes[quorum]; totalStake != nil && totalStake.Sign() > 0 {
+		share, _ = new(big.Rat).SetFrac(stake, totalStake).Float64()
+	}
+	metricOperatorStakeShare.WithLabelValues(operator.Name, e.network, quorumLabel).Set(share)
+}
+
+// stakeToFloat converts a stake from its 18 decimals representation.
+func stakeToFloat(stake *big.Int) float64 {
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(stake), big.NewFloat(params.Ether)).Float64()
+	return f
+}
@@ -0,0 +1,62 @@
+package eigenda
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda/contracts"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// currentTotalStake returns the CallContract function of an RPC client of a
+// full node answering the getCurrentTotalStake calls of the StakeRegistry at
+// the latest block, with the total stake or err. The calls at past blocks fail
+// as their state was pruned.
+func currentTotalStake(stakeRegistry *contracts.StakeRegistryContract, totalStake *big.Int, err error) func(msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return func(msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+		if blockNumber != nil {
+			return nil, errors.New("missing trie node")
+		}
+		if err != nil {
+			return nil, err
+		}
+		return stakeRegistry.Abi.Methods["getCurrentTotalStake"].Outputs.Pack(totalStake)
+	}
+}
+
+func TestProcessOperatorStakeUpdateLog(t *testing.T) {
+	ether := big.NewInt(params.Ether)
+	tests := []struct {
+		name  string
+		err   error
+		share float64
+	}{
+		{name: "total stake read at the latest block", share: 0.25},
+		{name: "total stake not available", err: errors.New("connection refused"), share: 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operator := &trackedOperator{OperatorConfig: config.OperatorConfig{Name: "operator"}, id: common.HexToHash("0x0a")}
+			client := &fakeRpc{}
+			e := newTestExporter(t, client, operator)
+			client.callContract = currentTotalStake(e.stakeRegistry, new(big.Int).Mul(big.NewInt(200), ether), tt.err)
+			// The total stake known from the last reconciliation
+			e.quorumTotalStakes[0] = new(big.Int).Mul(big.NewInt(100), ether)
+			event := e.stakeRegistry.Abi.Events["OperatorStakeUpdate"]
+			data, err := event.Inputs.NonIndexed().Pack(uint8(0), new(big.Int).Mul(big.NewInt(50), ether))
+			require.NoError(t, err)
+
+			require.NoError(t, e.processOperatorStakeUpdateLog(types.Log{BlockNumber: 100, Topics: []common.Hash{event.ID, operator.id}, Data: data}))
+
+			assert.Equal(t, float64(50), gaugeValue(t, metricOperatorStake.WithLabelValues(operator.Name, e.network, "0")))
+			assert.Equal(t, tt.share, gaugeValue(t, metricOperatorStakeShare.WithLabelValues(operator.Name, e.network, "0")))
+		})
+	}
+}
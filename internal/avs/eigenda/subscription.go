@@ -0,0 +1,103 @@
+package eigenda
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// subscription holds the WebSocket subscriptions used by the exporter in push
+// mode. A nil subscription returns nil channels, which block forever when used
+// in a select statement.
+type subscription struct {
+	heads   chan *types.Header
+	logs    chan types.Log
+	headSub ethereum.Subscription
+	logSub  ethereum.Subscription
+}
+
+// subscribe subscribes to the new heads and to the logs the exporter
+// processes.
+func (e *eigenDAOnChainExporter) subscribe(ctx context.Context) (*subscription, error) {
+	query, err := e.filterQuery()
+	if err != nil {
+		return nil, err
+	}
+	s := &subscription{
+		heads: make(chan *types.Header, 16),
+		logs:  make(chan types.Log, 64),
+	}
+	s.headSub, err = e.ethClient.SubscribeNewHead(ctx, s.heads)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to new heads: %v", err)
+	}
+	s.logSub, err = e.ethClient.SubscribeFilterLogs(ctx, query, s.logs)
+	if err != nil {
+		s.headSub.Unsubscribe()
+		return nil, fmt.Errorf("failed to subscribe to logs: %v", err)
+	}
+	slog.Info("subscribed to new heads and logs |", "avsEnv", e.avsEnv)
+	metricExporterSubscribed.WithLabelValues(e.network).Set(1)
+	return s, nil
+}
+
+// trySubscribe subscribes if the exporter is in push mode, logging any error
+// so the exporter falls back to polling.
+func (e *eigenDAOnChainExporter) trySubscribe(ctx context.Context) *subscription {
+	if !e.pushMode {
+		return nil
+	}
+	s, err := e.subscribe(ctx)
+	if err != nil {
+		slog.Error("failed to subscribe, falling back to polling |", "avsEnv", e.avsEnv, "error", err)
+		return nil
+	}
+	return s
+}
+
+func (s *subscription) unsubscribe() {
+	if s == nil {
+		return
+	}
+	s.headSub.Unsubscribe()
+	s.logSub.Unsubscribe()
+}
+
+func (s *subscription) headsCh() <-chan *types.Header {
+	if s == nil {
+		return nil
+	}
+	return s.heads
+}
+
+func (s *subscription) logsCh() <-chan types.Log {
+	if s == nil {
+		return nil
+	}
+	return s.logs
+}
+
+func (s *subscription) headErr() <-chan error {
+	if s == nil {
+		return nil
+	}
+	return s.headSub.Err()
+}
+
+func (s *subscription) logErr() <-chan error {
+	if s == nil {
+		return nil
+	}
+	return s.logSub.Err()
+}
+
+// drainLogs discards the logs already received, as a single block range
+// processes all of them.
+func (s *subscription) drainLogs() {
+	for len(s.logs) > 0 {
+		<-s.logs
+	}
+}
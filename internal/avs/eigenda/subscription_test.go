@@ -0,0 +1,101 @@
+package eigenda
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSubscription is a subscription remembering whether it was unsubscribed.
+type fakeSubscription struct {
+	err          chan error
+	unsubscribed bool
+}
+
+func (s *fakeSubscription) Err() <-chan error { return s.err }
+func (s *fakeSubscription) Unsubscribe()      { s.unsubscribed = true }
+
+func TestTrySubscribe(t *testing.T) {
+	tests := []struct {
+		name       string
+		pushMode   bool
+		headsErr   error
+		logsErr    error
+		subscribed bool
+		headSub    bool
+		logSub     bool
+	}{
+		{name: "polling mode"},
+		{name: "push mode", pushMode: true, subscribed: true, headSub: true, logSub: true},
+		{name: "new heads subscription failure", pushMode: true, headsErr: errors.New("notifications not supported")},
+		{name: "logs subscription failure", pushMode: true, logsErr: errors.New("notifications not supported"), headSub: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headSub := &fakeSubscription{err: make(chan error)}
+			logSub := &fakeSubscription{err: make(chan error)}
+			var headSubscribed, logSubscribed bool
+			e := newTestExporter(t, &fakeRpc{
+				subscribeHeads: func(ch chan<- *types.Header) (ethereum.Subscription, error) {
+					if tt.headsErr != nil {
+						return nil, tt.headsErr
+					}
+					headSubscribed = true
+					return headSub, nil
+				},
+				subscribeLogs: func(query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+					if tt.logsErr != nil {
+						return nil, tt.logsErr
+					}
+					logSubscribed = true
+					return logSub, nil
+				},
+			})
+			e.pushMode = tt.pushMode
+
+			s := e.trySubscribe(context.Background())
+
+			assert.Equal(t, tt.subscribed, s != nil)
+			assert.Equal(t, tt.headSub, headSubscribed)
+			assert.Equal(t, tt.logSub, logSubscribed)
+			// The subscriptions are not left open when the exporter falls
+			// back to polling
+			assert.Equal(t, headSubscribed && !tt.subscribed, headSub.unsubscribed)
+			if tt.subscribed {
+				assert.Equal(t, float64(1), gaugeValue(t, metricExporterSubscribed.WithLabelValues(e.network)))
+				s.unsubscribe()
+				assert.True(t, headSub.unsubscribed)
+				assert.True(t, logSub.unsubscribed)
+				return
+			}
+			assert.Equal(t, float64(0), gaugeValue(t, metricExporterSubscribed.WithLabelValues(e.network)))
+		})
+	}
+}
+
+func TestNilSubscription(t *testing.T) {
+	var s *subscription
+
+	// A nil subscription never delivers, so it is never selected
+	assert.Nil(t, s.headsCh())
+	assert.Nil(t, s.logsCh())
+	assert.Nil(t, s.headErr())
+	assert.Nil(t, s.logErr())
+	s.unsubscribe()
+}
+
+func TestDrainLogs(t *testing.T) {
+	s := &subscription{logs: make(chan types.Log, 4)}
+	for i := 0; i < 3; i++ {
+		s.logs <- types.Log{BlockNumber: uint64(i)}
+	}
+
+	s.drainLogs()
+
+	require.Empty(t, s.logs)
+}
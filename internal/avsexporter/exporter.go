@@ -9,4 +9,7 @@ import (
 type AVSExporter interface {
 	Name() string
 	Run(context.Context, *config.Config) error
+	// Backfill processes the given block range, both ends included, and
+	// returns once it is done.
+	Backfill(ctx context.Context, c *config.Config, fromBlock uint64, toBlock uint64) error
 }
@@ -0,0 +1,73 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Checkpoint is the progress of an AVS exporter.
+type Checkpoint struct {
+	// Block is the last settled block processed by the exporter.
+	Block uint64 `json:"block"`
+	// BlockHash is the hash of Block, used to detect if it was reorganized out
+	// of the chain while the exporter was down.
+	BlockHash string `json:"blockHash,omitempty"`
+}
+
+// Store persists the checkpoints of the AVS exporters keyed by AVS environment.
+type Store interface {
+	// Load returns the checkpoint of the given AVS environment, or nil if no
+	// checkpoint has been saved yet.
+	Load(avsEnv string) (*Checkpoint, error)
+	// Save stores the checkpoint of the given AVS environment.
+	Save(avsEnv string, checkpoint Checkpoint) error
+}
+
+type fileStore struct {
+	dataDir string
+}
+
+// NewFileStore returns a Store that keeps one JSON file per AVS environment
+// inside dataDir. The directory is created if it does not exist.
+func NewFileStore(dataDir string) (Store, error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create data dir: %v", err)
+	}
+	return &fileStore{dataDir: dataDir}, nil
+}
+
+func (s *fileStore) Load(avsEnv string) (*Checkpoint, error) {
+	data, err := os.ReadFile(s.path(avsEnv))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checkpoint: %v", err)
+	}
+	return &checkpoint, nil
+}
+
+func (s *fileStore) Save(avsEnv string, checkpoint Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %v", err)
+	}
+	// Write to a temporary file and rename it so a crash never leaves a
+	// partially written checkpoint behind.
+	tmpPath := s.path(avsEnv) + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path(avsEnv))
+}
+
+func (s *fileStore) path(avsEnv string) string {
+	return filepath.Join(s.dataDir, avsEnv+".checkpoint.json")
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avs/eigenda"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/avsexporter"
+	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func backfillCommand() *cobra.Command {
+	var (
+		c         *config.Config
+		avsEnv    string
+		fromBlock uint64
+		toBlock   uint64
+		// metricsAddress defaults to another address than the run command,
+		// so a backfill can run next to the exporter
+		metricsAddress string
+	)
+	cmd := &cobra.Command{
+		Use:   "backfill",
+		Short: "Rebuild the metrics of an AVS environment from a past block range",
+		Long: `Rebuild the metrics of an AVS environment from a past block range.
+
+The metrics are exposed while the range is processed and until the command is
+interrupted, so Prometheus can scrape the final values. The exporter checkpoint
+is not modified.`,
+		PreRunE: func(cmd *cobra.Command, args []string) (err error) {
+			c, err = loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+			return startPrometheusServer(metricsAddress)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			var exporter avsexporter.AVSExporter
+			switch avsEnv {
+			case config.AVSEnvEigenDAMainnet, config.AVSEnvEigenDAHolesky:
+				var err error
+				exporter, err = eigenda.NewEigenDAOnChainExporter(avsEnv, c)
+				if err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("invalid AVS environment: %s", avsEnv)
+			}
+
+			if err := exporter.Backfill(ctx, c, fromBlock, toBlock); err != nil {
+				return err
+			}
+			if ctx.Err() == nil {
+				slog.Info("serving backfilled metrics until interrupted", "exporter", exporter.Name())
+				<-ctx.Done()
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&avsEnv, "avs-env", "", "AVS environment to backfill (e.g. eigenda-mainnet)")
+	cmd.Flags().Uint64Var(&fromBlock, "from", 0, "first block of the range")
+	cmd.Flags().Uint64Var(&toBlock, "to", 0, "last block of the range")
+	cmd.Flags().StringVar(&metricsAddress, "metrics-address", ":9091", "address the metrics are served on")
+	for _, flag := range []string{"avs-env", "from", "to"} {
+		_ = cmd.MarkFlagRequired(flag)
+	}
+	return cmd
+}
@@ -11,6 +11,7 @@ func RootCmd() *cobra.Command {
 	}
 	rootCmd.PersistentFlags().StringP("config", "c", "eoe-config.yml", "path to config file")
 	rootCmd.AddCommand(runCommand())
+	rootCmd.AddCommand(backfillCommand())
 
 	return rootCmd
 }
@@ -23,27 +23,12 @@ func runCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "run",
 		Short: "Run the application",
-		PreRunE: func(cmd *cobra.Command, args []string) error {
-			configPath, err := cmd.Flags().GetString("config")
+		PreRunE: func(cmd *cobra.Command, args []string) (err error) {
+			c, err = loadConfig(cmd)
 			if err != nil {
 				return err
 			}
-			c, err = config.GetConfig(configPath)
-			if err != nil {
-				return err
-			}
-			logLevel := slog.Level(slog.LevelInfo)
-			if err := logLevel.UnmarshalText([]byte(c.LogLevel)); err != nil {
-				return err
-			}
-			slog.SetLogLoggerLevel(logLevel)
-			go func() {
-				err := prometheus.StartPrometheusServer(":9090")
-				if err != nil {
-					slog.Error("Error starting Prometheus server", "error", err)
-				}
-			}()
-			return nil
+			return startPrometheusServer(defaultMetricsAddress)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var (
@@ -111,6 +96,38 @@ func runExporter(
 	}()
 }
 
+// loadConfig reads the config file given by the --config flag and sets the
+// log level from it.
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	configPath, err := cmd.Flags().GetString("config")
+	if err != nil {
+		return nil, err
+	}
+	c, err := config.GetConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	logLevel := slog.Level(slog.LevelInfo)
+	if err := logLevel.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return nil, err
+	}
+	slog.SetLogLoggerLevel(logLevel)
+	return c, nil
+}
+
+// defaultMetricsAddress is the address the metrics of the run command are
+// served on.
+const defaultMetricsAddress = ":9090"
+
+// startPrometheusServer starts the Prometheus server on the given address in
+// the background.
+func startPrometheusServer(address string) error {
+	if err := prometheus.StartPrometheusServer(address); err != nil {
+		return fmt.Errorf("failed to start Prometheus server: %v", err)
+	}
+	return nil
+}
+
 func gracefulExit(wg *sync.WaitGroup, err error) error {
 	slog.Debug("Shutting down exporters...")
 	wg.Wait()
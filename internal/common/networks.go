@@ -1,6 +1,7 @@
 package common
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 )
@@ -10,15 +11,18 @@ const (
 	NetworkMainnet = "mainnet"
 )
 
+// ErrInvalidChainID is returned when the chain ID does not match the network.
+var ErrInvalidChainID = errors.New("invalid chain id")
+
 func AssertChainID(network string, chainId *big.Int) error {
 	switch network {
 	case NetworkHolesky:
 		if chainId.Cmp(big.NewInt(17000)) != 0 {
-			return fmt.Errorf("invalid chain id for network: %s", network)
+			return fmt.Errorf("%w for network: %s", ErrInvalidChainID, network)
 		}
 	case NetworkMainnet:
 		if chainId.Cmp(big.NewInt(1)) != 0 {
-			return fmt.Errorf("invalid chain id for network: %s", network)
+			return fmt.Errorf("%w for network: %s", ErrInvalidChainID, network)
 		}
 	default:
 		return fmt.Errorf("invalid network: %s", network)
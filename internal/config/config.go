@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 const (
 	// RPCNetwork is the network type for the RPC.
 	RPCNetworkEthereum = "ethereum"
@@ -8,16 +10,157 @@ const (
 	// AVSEnv is the environment for the AVS.
 	AVSEnvEigenDAHolesky = "eigenda-holesky"
 	AVSEnvEigenDAMainnet = "eigenda-mainnet"
+
+	// DefaultDataDir is the default directory where the exporters state is stored.
+	DefaultDataDir = "data"
+	// DefaultPollInterval is the default time between two block ranges.
+	DefaultPollInterval = 30 * time.Second
+	// DefaultPageSize is the default maximum number of blocks of a block range.
+	DefaultPageSize = 1000
+	// DefaultTxFetchConcurrency is the default number of transactions fetched
+	// concurrently.
+	DefaultTxFetchConcurrency = 8
+	// DefaultReconcileInterval is the default time between two reconciliations
+	// of the processed batches with the onchain state.
+	DefaultReconcileInterval = 10 * time.Minute
+	// DefaultNetworkAnalyticsWindow is the default time window of the
+	// network-wide non-signer analytics.
+	DefaultNetworkAnalyticsWindow = time.Hour
+	// DefaultNetworkAnalyticsTopN is the default number of operators in the
+	// missed batches leaderboard.
+	DefaultNetworkAnalyticsTopN = 10
+	// DefaultSigningRateBatches is the default number of last batches the
+	// signing rate of the operators is computed over.
+	DefaultSigningRateBatches = 100
+
+	// BlockTag is the block the exporters follow on a network.
+	BlockTagLatest    = "latest"
+	BlockTagSafe      = "safe"
+	BlockTagFinalized = "finalized"
 )
 
+// DefaultSigningRateWindows are the default time windows the signing rate of
+// the operators is computed over.
+var DefaultSigningRateWindows = []time.Duration{time.Hour, 24 * time.Hour}
+
 // Config is the configuration for the application.
 type Config struct {
 	// Operators is the list of operators to be tracked.
 	Operators []OperatorConfig `yaml:"operators"`
-	// RPCs is the list of RPCs to be used for the AVS exporters.
+	// RPCs is the RPC URL of each network to be used for the AVS exporters. Use
+	// Networks[network].RPCs to configure several endpoints for a network.
 	RPCs map[string]string `yaml:"rpcs"`
 	// LogLevel is the level of logging to be used.
 	LogLevel string `yaml:"logLevel"`
+	// DataDir is the directory where the exporters persist their state, such
+	// as the last processed block of each AVS environment.
+	DataDir string `yaml:"dataDir"`
+	// AVSEnvs is the configuration of each AVS environment exporter, keyed by
+	// AVS environment.
+	AVSEnvs map[string]AVSEnvConfig `yaml:"avsEnvs"`
+	// Networks is the configuration of each network, keyed by network.
+	Networks map[string]NetworkConfig `yaml:"networks"`
+}
+
+// NetworkConfig is the configuration for a network.
+type NetworkConfig struct {
+	// RPCs is the list of RPC endpoints of the network. The exporters use the
+	// healthiest endpoint with the lowest priority value and fail over to the
+	// others. If it is empty, the URL in Config.RPCs is used.
+	RPCs []RPCConfig `yaml:"rpcs"`
+	// BlockTag is the block the exporters follow: latest, safe or finalized.
+	// If it is empty, the latest block is followed.
+	BlockTag string `yaml:"blockTag"`
+	// Confirmations is the number of blocks the exporters stay behind the
+	// latest block. It is only used with the latest block tag.
+	Confirmations uint64 `yaml:"confirmations"`
+	// LogsConsensus is the configuration of the logs consensus mode.
+	LogsConsensus LogsConsensusConfig `yaml:"logsConsensus"`
+	// Retry is the retry policy of the failed RPC requests.
+	Retry RetryConfig `yaml:"retry"`
+}
+
+// RetryConfig is the exponential backoff policy of the failed RPC requests.
+// Fields that are not set take their default value.
+type RetryConfig struct {
+	// InitialInterval is the wait time before the first retry. Defaults to
+	// 500ms.
+	InitialInterval time.Duration `yaml:"initialInterval"`
+	// Multiplier is the factor the wait time grows by after each retry.
+	// Defaults to 1.5.
+	Multiplier float64 `yaml:"multiplier"`
+	// MaxInterval caps the wait time between two retries. Defaults to 10s.
+	MaxInterval time.Duration `yaml:"maxInterval"`
+	// MaxElapsedTime is the time after which a request is not retried anymore.
+	// Defaults to 1m.
+	MaxElapsedTime time.Duration `yaml:"maxElapsedTime"`
+	// MaxAttempts is the maximum number of attempts of a request, including
+	// the first one. Defaults to 5.
+	MaxAttempts uint64 `yaml:"maxAttempts"`
+}
+
+// LogsConsensusConfig is the configuration of the logs consensus mode, where the
+// logs of a block range are queried from several RPC endpoints and only
+// accepted when enough of them agree.
+type LogsConsensusConfig struct {
+	// Enabled enables the logs consensus mode.
+	Enabled bool `yaml:"enabled"`
+	// Endpoints is the number of RPC endpoints queried. If it is 0, every
+	// endpoint of the network is queried.
+	Endpoints int `yaml:"endpoints"`
+	// Quorum is the number of RPC endpoints that must return the same logs. If
+	// it is 0, a majority of the queried endpoints is required.
+	Quorum int `yaml:"quorum"`
+}
+
+// AVSEnvConfig is the configuration for an AVS environment exporter.
+type AVSEnvConfig struct {
+	// StartBlock is the block the exporter starts from when there is no
+	// checkpoint. If it is 0, the exporter starts from the latest block.
+	StartBlock uint64 `yaml:"startBlock"`
+	// PollInterval is the time between two block ranges. Defaults to 30s.
+	PollInterval time.Duration `yaml:"pollInterval"`
+	// PageSize is the maximum number of blocks of a block range. Defaults to
+	// 1000.
+	PageSize uint64 `yaml:"pageSize"`
+	// CatchUp makes the exporter process block ranges back-to-back, without
+	// waiting for the poll interval, while it is more than one page behind the
+	// latest block.
+	CatchUp bool `yaml:"catchUp"`
+	// TxFetchConcurrency is the number of batch transactions fetched
+	// concurrently when processing a block range. Defaults to 8.
+	TxFetchConcurrency int `yaml:"txFetchConcurrency"`
+	// ReconcileInterval is the time between two checks that the exporter saw
+	// every batch confirmed onchain. Defaults to 10m.
+	ReconcileInterval time.Duration `yaml:"reconcileInterval"`
+	// NetworkAnalytics is the configuration of the network-wide non-signer
+	// analytics.
+	NetworkAnalytics NetworkAnalyticsConfig `yaml:"networkAnalytics"`
+	// SigningRate is the configuration of the signing rate of the operators.
+	SigningRate SigningRateConfig `yaml:"signingRate"`
+}
+
+// SigningRateConfig is the configuration of the signing rate of the operators.
+type SigningRateConfig struct {
+	// Batches is the number of last batches of the operator the signing rate
+	// is computed over. Defaults to 100.
+	Batches int `yaml:"batches"`
+	// Windows are the time windows the signing rate is also computed over.
+	// Defaults to 1h and 24h.
+	Windows []time.Duration `yaml:"windows"`
+}
+
+// NetworkAnalyticsConfig is the configuration of the analytics of every
+// non-signer of the batches, including the operators that are not tracked.
+type NetworkAnalyticsConfig struct {
+	// Enabled enables the network-wide non-signer analytics.
+	Enabled bool `yaml:"enabled"`
+	// Window is the time window of the unique non-signers and of the missed
+	// batches leaderboard. Defaults to 1h.
+	Window time.Duration `yaml:"window"`
+	// TopN is the number of operators in the missed batches leaderboard.
+	// Defaults to 10.
+	TopN int `yaml:"topN"`
 }
 
 // OperatorConfig holds the needed information for an operator to be tracked.
@@ -26,7 +169,9 @@ type OperatorConfig struct {
 	Name string `yaml:"name"`
 	// Address is the address of the operator.
 	Address string `yaml:"address"`
-	// BLSPublicKey is the BLS public key of the operator.
+	// BLSPublicKey is the BLS public key of the operator, as the decimal X and
+	// Y coordinates. If it is empty, the key registered in the BLSApkRegistry
+	// is used.
 	BLSPublicKey [2]string `yaml:"blsPublicKey"`
 	// AVSEnvs is the list of AVS environments to be tracked.
 	AVSEnvs []string `yaml:"avsEnvs"`
@@ -39,5 +184,36 @@ type EigenDAConfig struct {
 	// receives events in the future about the operator's quorum status, it will
 	// update the status in the Prometheus metric. This map is only for bootstrapping
 	// and does not have a missing Prometheus metric.
+	//
+	// Deprecated: the status is read from the RegistryCoordinator when the
+	// exporter starts. This map is only used if it cannot be read.
 	Quorums map[int]bool `yaml:"quorums"`
 }
+
+// RPCConfig is the configuration for an RPC endpoint.
+type RPCConfig struct {
+	// Name identifies the endpoint in logs and metrics. If it is empty, the
+	// host of the URL is used.
+	Name string `yaml:"name"`
+	// URL is the HTTP or WebSocket URL of the endpoint.
+	URL string `yaml:"url"`
+	// Priority orders the endpoints. Lower values are preferred.
+	Priority int `yaml:"priority"`
+	// RPS is the maximum average number of requests per second sent to the
+	// endpoint. If it is 0, the requests are not rate limited.
+	RPS float64 `yaml:"rps"`
+	// Burst is the maximum number of requests sent at once to the endpoint
+	// when it is rate limited. If it is 0, it is one second of requests.
+	Burst int `yaml:"burst"`
+}
+
+// NetworkRPCs returns the RPC endpoints of the given network.
+func (c *Config) NetworkRPCs(network string) []RPCConfig {
+	if rpcs := c.Networks[network].RPCs; len(rpcs) > 0 {
+		return rpcs
+	}
+	if url, ok := c.RPCs[network]; ok {
+		return []RPCConfig{{URL: url}}
+	}
+	return nil
+}
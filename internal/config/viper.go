@@ -23,10 +23,21 @@ func GetConfig(configPath string) (*Config, error) {
 	viper.SetEnvPrefix("EOE")
 	viper.AutomaticEnv()
 
+	// Set default values
+	viper.SetDefault("dataDir", DefaultDataDir)
+
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, err
 	}
 
 	var c Config
-	return &c, viper.Unmarshal(&c)
+	if err := viper.Unmarshal(&c); err != nil {
+		return nil, err
+	}
+	// An explicitly empty data directory overrides the default, so fall back
+	// to it here.
+	if c.DataDir == "" {
+		c.DataDir = DefaultDataDir
+	}
+	return &c, nil
 }
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetConfigDataDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		dataDir string
+	}{
+		{name: "not set", config: "networks: {}\n", dataDir: DefaultDataDir},
+		{name: "empty", config: "dataDir: \"\"\n", dataDir: DefaultDataDir},
+		{name: "set", config: "dataDir: /var/lib/eoe\n", dataDir: "/var/lib/eoe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "eoe-config.yml")
+			require.NoError(t, os.WriteFile(configPath, []byte(tt.config), 0o644))
+
+			c, err := GetConfig(configPath)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.dataDir, c.DataDir)
+		})
+	}
+}
@@ -1,13 +1,26 @@
 package prometheus
 
 import (
+	"log/slog"
+	"net"
 	"net/http"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-// StartPrometheusServer starts a Prometheus server on the given port.
-func StartPrometheusServer(port string) error {
-	http.Handle("/metrics", promhttp.Handler())
-	return http.ListenAndServe(port, nil)
+// StartPrometheusServer starts a Prometheus server on the given address in the
+// background. It returns an error if the address cannot be listened on.
+func StartPrometheusServer(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			slog.Error("Error serving Prometheus metrics", "error", err)
+		}
+	}()
+	return nil
 }
@@ -0,0 +1,25 @@
+package rpc
+
+// computeUnits is the estimated cost of the JSON-RPC methods in compute units,
+// following the pricing of the main RPC providers.
+var computeUnits = map[string]float64{
+	"eth_blockNumber":          10,
+	"eth_chainId":              0,
+	"eth_getBlockByNumber":     16,
+	"eth_getLogs":              75,
+	"eth_getTransactionByHash": 17,
+	"eth_call":                 26,
+	"debug_traceTransaction":   309,
+}
+
+// defaultComputeUnits is the estimated cost of the methods missing from
+// computeUnits.
+const defaultComputeUnits = 20
+
+// methodComputeUnits returns the estimated cost of a JSON-RPC method.
+func methodComputeUnits(method string) float64 {
+	if units, ok := computeUnits[method]; ok {
+		return units
+	}
+	return defaultComputeUnits
+}
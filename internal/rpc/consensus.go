@@ -0,0 +1,152 @@
+package rpc
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// logsConsensus is the configuration of the logs consensus mode, where the logs
+// of a block range are only accepted when enough endpoints agree on them.
+type logsConsensus struct {
+	// endpoints is the number of endpoints queried. If it is 0, every endpoint
+	// is queried.
+	endpoints int
+	// quorum is the number of endpoints that must return the same logs. If it
+	// is 0, a majority of the queried endpoints is required.
+	quorum int
+}
+
+func (e *ethEvmRpc) checkLogsConsensus() error {
+	if e.logsConsensus == nil {
+		return nil
+	}
+	available := len(e.endpoints.endpoints)
+	if e.logsConsensus.endpoints == 0 || e.logsConsensus.endpoints > available {
+		e.logsConsensus.endpoints = available
+	}
+	if e.logsConsensus.quorum == 0 {
+		e.logsConsensus.quorum = e.logsConsensus.endpoints/2 + 1
+	}
+	if e.logsConsensus.quorum > e.logsConsensus.endpoints {
+		return fmt.Errorf("logs consensus quorum %d is greater than the %d endpoints of network %s", e.logsConsensus.quorum, e.logsConsensus.endpoints, e.network)
+	}
+	return nil
+}
+
+// filterLogsConsensus queries the logs from the most preferred endpoints and
+// returns the logs returned by at least quorum of them. The logs of each
+// endpoint are identified together with its hash of the last block of the
+// range, so endpoints on different chains do not agree. Endpoints returning
+// different logs are reported as disagreements, while endpoints whose head is
+// before the last block of the range do not vote.
+func (e *ethEvmRpc) filterLogsConsensus(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	type result struct {
+		endpoint *endpoint
+		logs     []types.Log
+		digest   ethcommon.Hash
+		// behind is set if the endpoint head is before the last block of the
+		// range
+		behind bool
+		err    error
+	}
+	endpoints := e.endpoints.ranked()[:e.logsConsensus.endpoints]
+	results := make([]result, len(endpoints))
+	var wg sync.WaitGroup
+	for i, active := range endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slog.Debug("filtering logs |", "rpc-network", e.network, "endpoint", active.Name)
+			logs, err := request(ctx, e, active, "eth_getLogs", func(client *ethclient.Client) ([]types.Log, error) {
+				return client.FilterLogs(ctx, query)
+			})
+			if err != nil {
+				results[i] = result{endpoint: active, err: err}
+				return
+			}
+			var toBlockHash ethcommon.Hash
+			if query.ToBlock != nil {
+				header, err := request(ctx, e, active, "eth_getBlockByNumber", func(client *ethclient.Client) (*types.Header, error) {
+					return client.HeaderByNumber(ctx, query.ToBlock)
+				})
+				if errors.Is(err, ethereum.NotFound) {
+					results[i] = result{endpoint: active, behind: true}
+					return
+				}
+				if err != nil {
+					results[i] = result{endpoint: active, err: err}
+					return
+				}
+				toBlockHash = header.Hash()
+			}
+			results[i] = result{endpoint: active, logs: logs, digest: logsDigest(toBlockHash, logs)}
+		}()
+	}
+	wg.Wait()
+
+	// Group the endpoints by the logs they returned
+	votes := make(map[ethcommon.Hash][]result)
+	var winner ethcommon.Hash
+	for _, r := range results {
+		if r.err != nil {
+			slog.Warn("failed to filter logs for consensus |", "rpc-network", e.network, "endpoint", r.endpoint.Name, "error", r.err)
+			continue
+		}
+		if r.behind {
+			slog.Warn("rpc endpoint is behind the logs range, skipping its logs |", "rpc-network", e.network, "endpoint", r.endpoint.Name, "toBlock", query.ToBlock)
+			continue
+		}
+		votes[r.digest] = append(votes[r.digest], r)
+		if len(votes[r.digest]) > len(votes[winner]) {
+			winner = r.digest
+		}
+	}
+
+	agreed := len(votes[winner]) >= e.logsConsensus.quorum
+	for digest, group := range votes {
+		if agreed && digest == winner {
+			continue
+		}
+		for _, r := range group {
+			slog.Warn("rpc endpoint disagrees on logs |", "rpc-network", e.network, "endpoint", r.endpoint.Name, "fromBlock", query.FromBlock, "toBlock", query.ToBlock, "logs", len(r.logs))
+			metricLogsConsensusDisagreements.WithLabelValues(e.network, r.endpoint.Name).Inc()
+		}
+	}
+	if !agreed {
+		metricLogsConsensusFailures.WithLabelValues(e.network).Inc()
+		return nil, fmt.Errorf("no logs consensus: %d of %d endpoints agree, %d required", len(votes[winner]), len(endpoints), e.logsConsensus.quorum)
+	}
+	return votes[winner][0].logs, nil
+}
+
+// logsDigest returns a hash identifying the set of logs by their block hash,
+// transaction hash and index, and the hash of the last block of the range they
+// were returned for.
+func logsDigest(toBlockHash ethcommon.Hash, logs []types.Log) ethcommon.Hash {
+	keys := make([][]byte, 0, len(logs))
+	for _, l := range logs {
+		key := make([]byte, 0, 2*ethcommon.HashLength+9)
+		key = append(key, l.BlockHash.Bytes()...)
+		key = append(key, l.TxHash.Bytes()...)
+		key = binary.BigEndian.AppendUint64(key, uint64(l.Index))
+		if l.Removed {
+			key = append(key, 1)
+		} else {
+			key = append(key, 0)
+		}
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b []byte) int { return slices.Compare(a, b) })
+	return crypto.Keccak256Hash(append([][]byte{toBlockHash.Bytes()}, keys...)...)
+}
@@ -0,0 +1,166 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLogs(blockHash string) []types.Log {
+	return []types.Log{{
+		Address:     ethcommon.HexToAddress("0x1"),
+		Topics:      []ethcommon.Hash{ethcommon.HexToHash("0x2")},
+		BlockNumber: 1,
+		BlockHash:   ethcommon.HexToHash(blockHash),
+		TxHash:      ethcommon.HexToHash("0x3"),
+	}}
+}
+
+func TestFilterLogsConsensus(t *testing.T) {
+	canonical := testLogs("0xa")
+	orphaned := testLogs("0xb")
+	tests := []struct {
+		name          string
+		services      []*fakeEthService
+		quorum        int
+		logs          []types.Log
+		disagreements []float64
+		failure       bool
+	}{
+		{
+			name:          "every endpoint agrees",
+			services:      []*fakeEthService{{logs: canonical}, {logs: canonical}, {logs: canonical}},
+			logs:          canonical,
+			disagreements: []float64{0, 0, 0},
+		},
+		{
+			name:          "majority agrees",
+			services:      []*fakeEthService{{logs: orphaned}, {logs: canonical}, {logs: canonical}},
+			logs:          canonical,
+			disagreements: []float64{1, 0, 0},
+		},
+		{
+			name:          "failed endpoint",
+			services:      []*fakeEthService{{errs: []error{errors.New("timeout")}}, {logs: canonical}, {logs: canonical}},
+			logs:          canonical,
+			disagreements: []float64{0, 0, 0},
+		},
+		{
+			name:          "endpoint on another chain",
+			services:      []*fakeEthService{{logs: canonical, fork: "b"}, {logs: canonical}, {logs: canonical}},
+			logs:          canonical,
+			disagreements: []float64{1, 0, 0},
+		},
+		{
+			name:          "endpoint behind the range",
+			services:      []*fakeEthService{{behind: true}, {logs: canonical}, {logs: canonical}},
+			logs:          canonical,
+			disagreements: []float64{0, 0, 0},
+		},
+		{
+			name:          "no quorum with endpoints behind the range",
+			services:      []*fakeEthService{{logs: canonical}, {behind: true}, {behind: true}},
+			disagreements: []float64{1, 0, 0},
+			failure:       true,
+		},
+		{
+			name:          "no quorum",
+			services:      []*fakeEthService{{logs: canonical}, {logs: orphaned}, {errs: []error{errors.New("timeout")}}},
+			disagreements: []float64{1, 1, 0},
+			failure:       true,
+		},
+		{
+			name:          "no quorum with a unanimity quorum",
+			services:      []*fakeEthService{{logs: canonical}, {logs: canonical}, {logs: orphaned}},
+			quorum:        3,
+			disagreements: []float64{1, 1, 1},
+			failure:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			network := t.Name()
+			endpoints := make([]*endpoint, len(tt.services))
+			for i, service := range tt.services {
+				endpoints[i] = newFakeEndpoint(t, string(rune('a'+i)), i, service)
+			}
+			e := newTestRpc(t, endpoints...)
+			e.logsConsensus = &logsConsensus{quorum: tt.quorum}
+			require.NoError(t, e.checkLogsConsensus())
+			// The metrics are global, so only their increase is checked
+			failures := counterValue(t, metricLogsConsensusFailures.WithLabelValues(network))
+			disagreements := make([]float64, len(endpoints))
+			for i, endpoint := range endpoints {
+				disagreements[i] = counterValue(t, metricLogsConsensusDisagreements.WithLabelValues(network, endpoint.Name))
+			}
+
+			logs, err := e.filterLogsConsensus(context.Background(), ethereum.FilterQuery{FromBlock: big.NewInt(1), ToBlock: big.NewInt(1)})
+
+			if tt.failure {
+				assert.Error(t, err)
+				assert.Equal(t, failures+1, counterValue(t, metricLogsConsensusFailures.WithLabelValues(network)))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, logsDigest(ethcommon.Hash{}, tt.logs), logsDigest(ethcommon.Hash{}, logs))
+			}
+			for i, endpoint := range endpoints {
+				assert.Equal(t, disagreements[i]+tt.disagreements[i], counterValue(t, metricLogsConsensusDisagreements.WithLabelValues(network, endpoint.Name)), endpoint.Name)
+			}
+		})
+	}
+}
+
+func TestCheckLogsConsensus(t *testing.T) {
+	tests := []struct {
+		name      string
+		consensus logsConsensus
+		expected  logsConsensus
+		err       bool
+	}{
+		{name: "defaults", consensus: logsConsensus{}, expected: logsConsensus{endpoints: 4, quorum: 3}},
+		{name: "more endpoints than available", consensus: logsConsensus{endpoints: 10}, expected: logsConsensus{endpoints: 4, quorum: 3}},
+		{name: "subset of the endpoints", consensus: logsConsensus{endpoints: 3}, expected: logsConsensus{endpoints: 3, quorum: 2}},
+		{name: "quorum", consensus: logsConsensus{endpoints: 2, quorum: 1}, expected: logsConsensus{endpoints: 2, quorum: 1}},
+		{name: "quorum greater than the endpoints", consensus: logsConsensus{endpoints: 2, quorum: 3}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoints := make([]*endpoint, 4)
+			for i := range endpoints {
+				endpoints[i] = newTestEndpoint(string(rune('a'+i)), "https://endpoint", i)
+			}
+			consensus := tt.consensus
+			e := newTestRpc(t, endpoints...)
+			e.logsConsensus = &consensus
+
+			err := e.checkLogsConsensus()
+
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, consensus)
+		})
+	}
+}
+
+func TestLogsDigest(t *testing.T) {
+	a, b := testLogs("0xa")[0], testLogs("0xb")[0]
+	removed := a
+	removed.Removed = true
+	toBlockHash := ethcommon.HexToHash("0xc")
+
+	assert.Equal(t, logsDigest(toBlockHash, []types.Log{a, b}), logsDigest(toBlockHash, []types.Log{b, a}), "order does not matter")
+	assert.NotEqual(t, logsDigest(toBlockHash, []types.Log{a}), logsDigest(toBlockHash, []types.Log{b}))
+	assert.NotEqual(t, logsDigest(toBlockHash, []types.Log{a}), logsDigest(toBlockHash, []types.Log{removed}))
+	assert.NotEqual(t, logsDigest(toBlockHash, []types.Log{a}), logsDigest(toBlockHash, nil))
+	assert.NotEqual(t, logsDigest(toBlockHash, nil), logsDigest(ethcommon.HexToHash("0xd"), nil), "the chain matters")
+}
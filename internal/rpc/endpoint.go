@@ -0,0 +1,214 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	// healthDecay is the weight of the previous health score when a new request
+	// result is recorded.
+	healthDecay = 0.5
+	// healthyThreshold is the health score under which an endpoint is not used
+	// while a healthier one is available.
+	healthyThreshold = 0.5
+	// unhealthyCooldown is the time after which an unhealthy endpoint is tried
+	// again.
+	unhealthyCooldown = 30 * time.Second
+)
+
+// Endpoint is an RPC endpoint of a network.
+type Endpoint struct {
+	// Name identifies the endpoint in logs and metrics.
+	Name string
+	// URL is the HTTP or WebSocket URL of the endpoint.
+	URL string
+	// Priority orders the endpoints. Lower values are preferred.
+	Priority int
+	// RPS is the maximum average number of requests per second sent to the
+	// endpoint. If it is 0, the requests are not rate limited.
+	RPS float64
+	// Burst is the maximum number of requests sent at once to the endpoint
+	// when it is rate limited. If it is 0, it is one second of requests.
+	Burst int
+}
+
+// IsWebSocketURL reports whether the RPC URL supports subscriptions.
+func IsWebSocketURL(rpcURL string) bool {
+	u, err := url.Parse(rpcURL)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "ws" || u.Scheme == "wss"
+}
+
+// EndpointName returns the name used for an endpoint without a configured
+// name: the host of its URL, so credentials in the path or query are not
+// exposed in logs and metrics.
+func EndpointName(rpcURL string) string {
+	u, err := url.Parse(rpcURL)
+	if err != nil || u.Host == "" {
+		return "unknown"
+	}
+	return u.Host
+}
+
+type endpoint struct {
+	Endpoint
+	// client is nil until the endpoint is dialed
+	client  *ethclient.Client
+	dial    func() (*ethclient.Client, error)
+	dialMu  sync.Mutex
+	limiter *rateLimiter
+	// health is an exponentially weighted moving average of the request
+	// results, from 0 (every request failed) to 1 (every request succeeded).
+	health      float64
+	lastFailure time.Time
+}
+
+// endpointPool tracks the health of the endpoints of a network and selects the
+// active one.
+type endpointPool struct {
+	network   string
+	mu        sync.Mutex
+	endpoints []*endpoint
+	current   *endpoint
+}
+
+func newEndpointPool(network string, endpoints []*endpoint) *endpointPool {
+	p := &endpointPool{network: network, endpoints: endpoints}
+	for _, e := range endpoints {
+		metricEndpointHealth.WithLabelValues(network, e.Name).Set(e.health)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.selectEndpoint()
+	return p
+}
+
+// active returns the endpoint the requests are sent to.
+func (p *endpointPool) active() *endpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.selectEndpoint()
+	return p.current
+}
+
+// activeWebSocket returns the preferred healthy WebSocket endpoint, as only
+// those support subscriptions.
+func (p *endpointPool) activeWebSocket() (*endpoint, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var best *endpoint
+	for _, e := range p.endpoints {
+		if IsWebSocketURL(e.URL) && betterEndpoint(e, best) {
+			best = e
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no WebSocket RPC endpoint for network: %s", p.network)
+	}
+	return best, nil
+}
+
+// ranked returns the endpoints from the most to the least preferred.
+func (p *endpointPool) ranked() []*endpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	endpoints := slices.Clone(p.endpoints)
+	slices.SortStableFunc(endpoints, func(a, b *endpoint) int {
+		if betterEndpoint(a, b) {
+			return -1
+		}
+		if betterEndpoint(b, a) {
+			return 1
+		}
+		return 0
+	})
+	return endpoints
+}
+
+// report records the result of a request sent to the endpoint. Results of
+// canceled requests and request errors do not say anything about the
+// endpoint health and are ignored.
+func (p *endpointPool) report(ctx context.Context, e *endpoint, err error) {
+	if ctx.Err() != nil || isRequestError(err) {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		e.health = e.health * healthDecay
+		e.lastFailure = time.Now()
+	} else {
+		e.health = e.health*healthDecay + (1 - healthDecay)
+	}
+	metricEndpointHealth.WithLabelValues(p.network, e.Name).Set(e.health)
+	p.selectEndpoint()
+}
+
+// selectEndpoint sets the current endpoint to the best one. It must be called
+// with the lock held.
+func (p *endpointPool) selectEndpoint() {
+	var best *endpoint
+	for _, e := range p.endpoints {
+		if betterEndpoint(e, best) {
+			best = e
+		}
+	}
+	if best == p.current {
+		return
+	}
+	if p.current != nil {
+		slog.Warn("switching rpc endpoint |", "rpc-network", p.network, "from", p.current.Name, "fromHealth", p.current.health, "to", best.Name, "toHealth", best.health)
+		metricEndpointSwitches.WithLabelValues(p.network).Inc()
+		metricActiveEndpoint.WithLabelValues(p.network, p.current.Name).Set(0)
+	}
+	metricActiveEndpoint.WithLabelValues(p.network, best.Name).Set(1)
+	p.current = best
+}
+
+// betterEndpoint reports whether endpoint a should be preferred over endpoint
+// b. Usable endpoints are preferred over unusable ones, then lower priority
+// values and then higher health scores.
+func betterEndpoint(a, b *endpoint) bool {
+	if b == nil {
+		return true
+	}
+	if a.usable() != b.usable() {
+		return a.usable()
+	}
+	if a.usable() && a.Priority != b.Priority {
+		return a.Priority < b.Priority
+	}
+	return a.health > b.health
+}
+
+// connect returns the client of the endpoint, dialing the endpoint if it could
+// not be dialed before.
+func (e *endpoint) connect() (*ethclient.Client, error) {
+	e.dialMu.Lock()
+	defer e.dialMu.Unlock()
+	if e.client == nil {
+		client, err := e.dial()
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial: %w", err)
+		}
+		slog.Info("dialed rpc endpoint |", "endpoint", e.Name)
+		e.client = client
+	}
+	return e.client, nil
+}
+
+// usable reports whether the endpoint is healthy or has cooled down since its
+// last failure.
+func (e *endpoint) usable() bool {
+	return e.health >= healthyThreshold || time.Since(e.lastFailure) > unhealthyCooldown
+}
@@ -0,0 +1,103 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+
+	"github.com/ethereum/go-ethereum"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// jsonRPCLimitExceeded is the JSON-RPC error code used by most providers
+	// when a request is rate limited.
+	jsonRPCLimitExceeded = -32005
+	// jsonRPCExecutionReverted is the JSON-RPC error code of a reverted call.
+	jsonRPCExecutionReverted = 3
+)
+
+// permanentJSONRPCErrorCodes are the JSON-RPC error codes caused by the request
+// itself, so retrying it or sending it to another endpoint does not help.
+var permanentJSONRPCErrorCodes = []int{
+	-32700, // Parse error
+	-32600, // Invalid request
+	-32601, // Method not found
+	-32602, // Invalid params
+	jsonRPCExecutionReverted,
+}
+
+// classifyError returns the class of a request error used in the metrics:
+// timeout, rate_limit, http_<status>, jsonrpc_<code>, not_found or other.
+func classifyError(err error) string {
+	var (
+		netErr     net.Error
+		httpErr    ethrpc.HTTPError
+		jsonRPCErr ethrpc.Error
+	)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.Is(err, ethereum.NotFound):
+		return "not_found"
+	case errors.As(err, &httpErr):
+		if httpErr.StatusCode == http.StatusTooManyRequests {
+			return "rate_limit"
+		}
+		return fmt.Sprintf("http_%d", httpErr.StatusCode)
+	case errors.As(err, &jsonRPCErr):
+		if jsonRPCErr.ErrorCode() == jsonRPCLimitExceeded {
+			return "rate_limit"
+		}
+		return fmt.Sprintf("jsonrpc_%d", jsonRPCErr.ErrorCode())
+	default:
+		return "other"
+	}
+}
+
+// isRequestError reports whether the error is caused by the request itself
+// rather than by the endpoint, so it does not affect the endpoint health.
+func isRequestError(err error) bool {
+	var jsonRPCErr ethrpc.Error
+	if errors.Is(err, ethereum.NotFound) {
+		return true
+	}
+	return errors.As(err, &jsonRPCErr) && slices.Contains(permanentJSONRPCErrorCodes, jsonRPCErr.ErrorCode())
+}
+
+// isRetryable reports whether a failed request should be retried. Request
+// errors, canceled requests and HTTP client errors are permanent, while
+// timeouts, rate limits, HTTP server errors and any other error are retried.
+func isRetryable(err error) bool {
+	var httpErr ethrpc.HTTPError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return false
+	case isRequestError(err):
+		return false
+	case errors.As(err, &httpErr):
+		return httpErr.StatusCode == http.StatusTooManyRequests ||
+			httpErr.StatusCode == http.StatusRequestTimeout ||
+			httpErr.StatusCode >= http.StatusInternalServerError
+	default:
+		return true
+	}
+}
+
+// IsExecutionReverted reports whether the error is caused by a reverted call.
+func IsExecutionReverted(err error) bool {
+	var jsonRPCErr ethrpc.Error
+	return errors.As(err, &jsonRPCErr) && jsonRPCErr.ErrorCode() == jsonRPCExecutionReverted
+}
+
+// IsPermanent reports whether a request failed because of the request itself
+// or of the endpoint, such as a method the endpoint does not expose, so sending
+// it again does not help.
+func IsPermanent(err error) bool {
+	return !errors.Is(err, context.Canceled) && !isRetryable(err)
+}
@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// timeoutError is a network error caused by a timeout.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func httpError(statusCode int) error {
+	return ethrpc.HTTPError{StatusCode: statusCode, Status: http.StatusText(statusCode)}
+}
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		class     string
+		retryable bool
+		request   bool
+		permanent bool
+	}{
+		{name: "deadline exceeded", err: context.DeadlineExceeded, class: "timeout", retryable: true},
+		{name: "network timeout", err: timeoutError{}, class: "timeout", retryable: true},
+		{name: "canceled", err: context.Canceled, class: "other"},
+		{name: "not found", err: ethereum.NotFound, class: "not_found", request: true, permanent: true},
+		{name: "http too many requests", err: httpError(http.StatusTooManyRequests), class: "rate_limit", retryable: true},
+		{name: "http request timeout", err: httpError(http.StatusRequestTimeout), class: "http_408", retryable: true},
+		{name: "http bad gateway", err: httpError(http.StatusBadGateway), class: "http_502", retryable: true},
+		{name: "http forbidden", err: httpError(http.StatusForbidden), class: "http_403", permanent: true},
+		{name: "jsonrpc limit exceeded", err: jsonRPCError{code: jsonRPCLimitExceeded}, class: "rate_limit", retryable: true},
+		{name: "jsonrpc invalid params", err: jsonRPCError{code: -32602}, class: "jsonrpc_-32602", request: true, permanent: true},
+		{name: "jsonrpc method not found", err: jsonRPCError{code: -32601}, class: "jsonrpc_-32601", request: true, permanent: true},
+		{name: "jsonrpc execution reverted", err: jsonRPCError{code: jsonRPCExecutionReverted}, class: "jsonrpc_3", request: true, permanent: true},
+		{name: "jsonrpc internal error", err: jsonRPCError{code: -32603}, class: "jsonrpc_-32603", retryable: true},
+		{name: "wrapped", err: fmt.Errorf("endpoint a: %w", jsonRPCError{code: -32602}), class: "jsonrpc_-32602", request: true, permanent: true},
+		{name: "other", err: errors.New("connection refused"), class: "other", retryable: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.class, classifyError(tt.err), "class")
+			assert.Equal(t, tt.retryable, isRetryable(tt.err), "retryable")
+			assert.Equal(t, tt.request, isRequestError(tt.err), "request error")
+			assert.Equal(t, tt.permanent, IsPermanent(tt.err), "permanent")
+		})
+	}
+}
+
+func TestIsExecutionReverted(t *testing.T) {
+	assert.True(t, IsExecutionReverted(fmt.Errorf("endpoint a: %w", jsonRPCError{code: jsonRPCExecutionReverted})))
+	assert.False(t, IsExecutionReverted(jsonRPCError{code: -32602}))
+	assert.False(t, IsExecutionReverted(errors.New("execution reverted")))
+}
@@ -2,7 +2,10 @@ package rpc
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"math/big"
 	"time"
 
 	"github.com/NethermindEth/eigenlayer-onchain-exporter/internal/common"
@@ -11,31 +14,94 @@ import (
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
-)
-
-var (
-	ethEvmRpcs = make(map[string]EthEvmRpc)
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
 )
 
 // EthEvmRpc is the interface for the Ethereum RPC client.
 type EthEvmRpc interface {
 	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SafeHeader(ctx context.Context) (*types.Header, error)
+	FinalizedHeader(ctx context.Context) (*types.Header, error)
 	FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error)
 	TransactionByHash(ctx context.Context, hash ethcommon.Hash) (*types.Transaction, bool, error)
+	TraceTransaction(ctx context.Context, hash ethcommon.Hash) (*CallFrame, error)
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	// SubscribeFilterLogs and SubscribeNewHead are only supported by WebSocket
+	// RPC endpoints.
+	SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
 }
 
 type ethEvmRpc struct {
-	network        string
-	client         *ethclient.Client
-	maxElapsedTime time.Duration
+	network       string
+	endpoints     *endpointPool
+	retryPolicy   RetryPolicy
+	logsConsensus *logsConsensus
 }
 
-func NewEthEvmRpc(network string, url string, maxElapsedTime time.Duration) (EthEvmRpc, error) {
-	if _, ok := ethEvmRpcs[network]; ok {
-		return ethEvmRpcs[network], nil
+// Option configures the RPC client of a network.
+type Option func(*ethEvmRpc)
+
+// WithLogsConsensus makes FilterLogs query the given number of endpoints and
+// only accept the logs returned by at least quorum of them.
+func WithLogsConsensus(endpoints int, quorum int) Option {
+	return func(e *ethEvmRpc) {
+		e.logsConsensus = &logsConsensus{endpoints: endpoints, quorum: quorum}
+	}
+}
+
+// NewEthEvmRpc returns a new RPC client of the network, so every caller uses its
+// own endpoints, retry policy and options. The rate limits of the endpoints are
+// shared by every client. The client sends the requests to the
+// healthiest endpoint with the lowest priority value, failing over to the other
+// endpoints when it fails. The endpoints that fail to be dialed are kept as
+// unhealthy and dialed again when they are tried after their cooldown.
+func NewEthEvmRpc(network string, endpoints []Endpoint, retryPolicy RetryPolicy, opts ...Option) (EthEvmRpc, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no RPC endpoints for network: %s", network)
+	}
+	slog.Debug("initializing new eth-evm rpc |", "network", network, "endpoints", len(endpoints))
+
+	var (
+		clients []*endpoint
+		dialed  int
+	)
+	for _, e := range endpoints {
+		dial := func() (*ethclient.Client, error) {
+			return dialEndpoint(network, e)
+		}
+		client, err := dial()
+		if errors.Is(err, common.ErrInvalidChainID) {
+			return nil, err
+		}
+		ep := &endpoint{Endpoint: e, client: client, dial: dial, health: 1, limiter: sharedRateLimiter(e.URL, e.RPS, e.Burst)}
+		if err != nil {
+			slog.Error("failed to dial rpc endpoint, retrying it later |", "rpc-network", network, "endpoint", e.Name, "error", err)
+			ep.health = 0
+			ep.lastFailure = time.Now()
+		} else {
+			dialed++
+		}
+		clients = append(clients, ep)
 	}
-	slog.Debug("initializing new eth-evm rpc |", "network", network)
-	client, err := ethclient.Dial(url)
+	if dialed == 0 {
+		return nil, fmt.Errorf("failed to dial any RPC endpoint for network: %s", network)
+	}
+
+	ethEvmRpc := &ethEvmRpc{network: network, endpoints: newEndpointPool(network, clients), retryPolicy: retryPolicy.withDefaults()}
+	for _, opt := range opts {
+		opt(ethEvmRpc)
+	}
+	if err := ethEvmRpc.checkLogsConsensus(); err != nil {
+		return nil, err
+	}
+
+	return ethEvmRpc, nil
+}
+
+func dialEndpoint(network string, e Endpoint) (*ethclient.Client, error) {
+	client, err := ethclient.Dial(e.URL)
 	if err != nil {
 		return nil, err
 	}
@@ -43,69 +109,158 @@ func NewEthEvmRpc(network string, url string, maxElapsedTime time.Duration) (Eth
 	// Check the chain ID of the network
 	chainId, err := client.ChainID(context.Background())
 	if err != nil {
+		client.Close()
 		return nil, err
 	}
 	err = common.AssertChainID(network, chainId)
 	if err != nil {
+		client.Close()
 		return nil, err
 	}
+	return client, nil
+}
 
-	ethEvmRpc := &ethEvmRpc{network: network, client: client, maxElapsedTime: maxElapsedTime}
-	ethEvmRpcs[network] = ethEvmRpc
-
-	return ethEvmRpc, nil
+// call runs the operation against the active endpoint, retrying with
+// exponential backoff. Every result updates the health of the endpoint, so a
+// retry may be sent to another endpoint.
+func call[T any](ctx context.Context, e *ethEvmRpc, method string, operation func(client *ethclient.Client) (T, error)) (T, error) {
+	return retry(ctx, e, method, func() (T, error) {
+		active := e.endpoints.active()
+		out, err := request(ctx, e, active, method, operation)
+		if err != nil {
+			err = fmt.Errorf("endpoint %s: %w", active.Name, err)
+			if !isRetryable(err) {
+				return out, backoff.Permanent(err)
+			}
+			return out, err
+		}
+		return out, nil
+	})
 }
 
-func (e *ethEvmRpc) BlockNumber(ctx context.Context) (uint64, error) {
-	operation := func() (uint64, error) {
-		slog.Debug("getting block number |", "rpcNetwork", e.network)
-		return e.client.BlockNumber(ctx)
+// request waits for the rate limiter of the endpoint and sends it the request,
+// recording its latency and result in the metrics and in the endpoint health.
+func request[T any](ctx context.Context, e *ethEvmRpc, active *endpoint, method string, operation func(client *ethclient.Client) (T, error)) (T, error) {
+	client, err := active.connect()
+	if err != nil {
+		e.endpoints.report(ctx, active, err)
+		var zero T
+		return zero, err
 	}
-	notify := func(err error, duration time.Duration) {
-		slog.Error("failed to get block number, retrying... |", "rpc-network", e.network, "duration", duration, "error", err)
+	priority := priorityFromContext(ctx)
+	start := time.Now()
+	if err := active.limiter.wait(ctx, priority); err != nil {
+		var zero T
+		return zero, err
 	}
+	metricRateLimitWait.WithLabelValues(e.network, active.Name, priority.String()).Observe(time.Since(start).Seconds())
+	metricComputeUnits.WithLabelValues(e.network, active.Name, method).Add(methodComputeUnits(method))
 
-	return backoff.RetryNotifyWithData(
-		operation,
-		backoff.NewExponentialBackOff(backoff.WithMaxElapsedTime(e.maxElapsedTime)),
-		notify,
-	)
+	start = time.Now()
+	out, err := operation(client)
+	metricRequestDuration.WithLabelValues(e.network, active.Name, method).Observe(time.Since(start).Seconds())
+	if err != nil && ctx.Err() == nil {
+		metricRequestErrors.WithLabelValues(e.network, active.Name, method, classifyError(err)).Inc()
+	}
+	e.endpoints.report(ctx, active, err)
+	return out, err
 }
 
-func (e *ethEvmRpc) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
-	operation := func() ([]types.Log, error) {
-		slog.Debug("filtering logs |", "rpc-network", e.network)
-		return e.client.FilterLogs(ctx, query)
-	}
+// retry runs the operation with the exponential backoff of the retry policy.
+// Errors wrapped with backoff.Permanent are not retried.
+func retry[T any](ctx context.Context, e *ethEvmRpc, method string, operation func() (T, error)) (T, error) {
 	notify := func(err error, duration time.Duration) {
-		slog.Error("failed to filter logs, retrying... |", "rpc-network", e.network, "duration", duration, "error", err)
+		slog.Error("rpc call failed, retrying... |", "rpc-network", e.network, "method", method, "duration", duration, "error", err)
+		metricRequestRetries.WithLabelValues(e.network, method).Inc()
 	}
 
 	return backoff.RetryNotifyWithData(
 		operation,
-		backoff.NewExponentialBackOff(backoff.WithMaxElapsedTime(e.maxElapsedTime)),
+		e.retryPolicy.backOff(ctx),
 		notify,
 	)
 }
 
+func (e *ethEvmRpc) BlockNumber(ctx context.Context) (uint64, error) {
+	return call(ctx, e, "eth_blockNumber", func(client *ethclient.Client) (uint64, error) {
+		slog.Debug("getting block number |", "rpc-network", e.network)
+		return client.BlockNumber(ctx)
+	})
+}
+
+func (e *ethEvmRpc) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return call(ctx, e, "eth_getBlockByNumber", func(client *ethclient.Client) (*types.Header, error) {
+		slog.Debug("getting header by number |", "rpc-network", e.network, "number", number)
+		return client.HeaderByNumber(ctx, number)
+	})
+}
+
+// SafeHeader returns the header of the latest safe block.
+func (e *ethEvmRpc) SafeHeader(ctx context.Context) (*types.Header, error) {
+	return e.HeaderByNumber(ctx, big.NewInt(ethrpc.SafeBlockNumber.Int64()))
+}
+
+// FinalizedHeader returns the header of the latest finalized block.
+func (e *ethEvmRpc) FinalizedHeader(ctx context.Context) (*types.Header, error) {
+	return e.HeaderByNumber(ctx, big.NewInt(ethrpc.FinalizedBlockNumber.Int64()))
+}
+
+func (e *ethEvmRpc) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	if e.logsConsensus != nil {
+		return retry(ctx, e, "eth_getLogs", func() ([]types.Log, error) {
+			return e.filterLogsConsensus(ctx, query)
+		})
+	}
+	return call(ctx, e, "eth_getLogs", func(client *ethclient.Client) ([]types.Log, error) {
+		slog.Debug("filtering logs |", "rpc-network", e.network)
+		return client.FilterLogs(ctx, query)
+	})
+}
+
 func (e *ethEvmRpc) TransactionByHash(ctx context.Context, hash ethcommon.Hash) (*types.Transaction, bool, error) {
 	type result struct {
 		tx        *types.Transaction
 		isPending bool
 	}
-	operation := func() (result, error) {
+	out, err := call(ctx, e, "eth_getTransactionByHash", func(client *ethclient.Client) (result, error) {
 		slog.Debug("getting transaction by hash |", "rpc-network", e.network, "hash", hash)
-		tx, isPending, err := e.client.TransactionByHash(ctx, hash)
+		tx, isPending, err := client.TransactionByHash(ctx, hash)
 		return result{tx: tx, isPending: isPending}, err
+	})
+	return out.tx, out.isPending, err
+}
+
+func (e *ethEvmRpc) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return call(ctx, e, "eth_call", func(client *ethclient.Client) ([]byte, error) {
+		slog.Debug("calling contract |", "rpc-network", e.network, "to", msg.To, "blockNumber", blockNumber)
+		return client.CallContract(ctx, msg, blockNumber)
+	})
+}
+
+func (e *ethEvmRpc) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	active, err := e.endpoints.activeWebSocket()
+	if err != nil {
+		return nil, err
 	}
-	notify := func(err error, duration time.Duration) {
-		slog.Error("failed to get transaction by hash, retrying... |", "rpc-network", e.network, "error", err)
+	slog.Debug("subscribing to filter logs |", "rpc-network", e.network, "endpoint", active.Name)
+	client, err := active.connect()
+	if err != nil {
+		e.endpoints.report(ctx, active, err)
+		return nil, err
 	}
+	return client.SubscribeFilterLogs(ctx, query, ch)
+}
 
-	out, err := backoff.RetryNotifyWithData(
-		operation,
-		backoff.NewExponentialBackOff(backoff.WithMaxElapsedTime(e.maxElapsedTime)),
-		notify,
-	)
-	return out.tx, out.isPending, err
+func (e *ethEvmRpc) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	active, err := e.endpoints.activeWebSocket()
+	if err != nil {
+		return nil, err
+	}
+	slog.Debug("subscribing to new heads |", "rpc-network", e.network, "endpoint", active.Name)
+	client, err := active.connect()
+	if err != nil {
+		e.endpoints.report(ctx, active, err)
+		return nil, err
+	}
+	return client.SubscribeNewHead(ctx, ch)
 }
@@ -0,0 +1,130 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestMetrics(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		errs  []error
+		class string
+	}{
+		{name: "success", ctx: context.Background()},
+		{name: "rate limited", ctx: context.Background(), errs: []error{jsonRPCError{code: jsonRPCLimitExceeded}}, class: "rate_limit"},
+		{name: "reverted call", ctx: context.Background(), errs: []error{jsonRPCError{code: jsonRPCExecutionReverted}}, class: "jsonrpc_3"},
+		{name: "other error", ctx: context.Background(), errs: []error{errors.New("internal error")}, class: "jsonrpc_-32000"},
+		{name: "canceled request", ctx: canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active := newFakeEndpoint(t, "a", 0, &fakeEthService{errs: tt.errs})
+			e := newTestRpc(t, active)
+			// The metrics are global, so only their increase is checked
+			duration := func() uint64 {
+				return histogramCount(t, metricRequestDuration.WithLabelValues(e.network, active.Name, "eth_blockNumber").(prometheus.Histogram))
+			}
+			errorsTotal := func(class string) float64 {
+				return counterValue(t, metricRequestErrors.WithLabelValues(e.network, active.Name, "eth_blockNumber", class))
+			}
+			durationBefore, errorsBefore := duration(), errorsTotal(tt.class)
+			computeUnits := counterValue(t, metricComputeUnits.WithLabelValues(e.network, active.Name, "eth_blockNumber"))
+
+			_, err := request(tt.ctx, e, active, "eth_blockNumber", func(client *ethclient.Client) (uint64, error) {
+				return client.BlockNumber(tt.ctx)
+			})
+
+			assert.Equal(t, durationBefore+1, duration())
+			assert.Equal(t, computeUnits+methodComputeUnits("eth_blockNumber"), counterValue(t, metricComputeUnits.WithLabelValues(e.network, active.Name, "eth_blockNumber")))
+			if tt.class == "" {
+				if tt.ctx.Err() == nil {
+					assert.NoError(t, err)
+				}
+				assert.Equal(t, errorsBefore, errorsTotal(""), "no error is counted")
+				return
+			}
+			assert.Error(t, err)
+			assert.Equal(t, errorsBefore+1, errorsTotal(tt.class))
+		})
+	}
+}
+
+func TestTaggedHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		header func(e *ethEvmRpc) (*types.Header, error)
+		number uint64
+	}{
+		{name: "safe", header: func(e *ethEvmRpc) (*types.Header, error) { return e.SafeHeader(context.Background()) }, number: 90},
+		{name: "finalized", header: func(e *ethEvmRpc) (*types.Header, error) { return e.FinalizedHeader(context.Background()) }, number: 80},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestRpc(t, newFakeEndpoint(t, "a", 0, &fakeEthService{}))
+
+			header, err := tt.header(e)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.number, header.Number.Uint64())
+		})
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	subscribeNewHead := func(e *ethEvmRpc) error {
+		sub, err := e.SubscribeNewHead(context.Background(), make(chan *types.Header))
+		if err == nil {
+			sub.Unsubscribe()
+		}
+		return err
+	}
+	subscribeFilterLogs := func(e *ethEvmRpc) error {
+		_, err := e.SubscribeFilterLogs(context.Background(), ethereum.FilterQuery{}, make(chan types.Log))
+		return err
+	}
+	tests := []struct {
+		name      string
+		subscribe func(e *ethEvmRpc) error
+		url       string
+		dialErr   error
+		err       bool
+	}{
+		{name: "new heads", subscribe: subscribeNewHead, url: "wss://a"},
+		{name: "new heads without WebSocket endpoint", subscribe: subscribeNewHead, url: "https://a", err: true},
+		{name: "logs without WebSocket endpoint", subscribe: subscribeFilterLogs, url: "https://a", err: true},
+		{name: "new heads from an endpoint failing to be dialed", subscribe: subscribeNewHead, url: "wss://a", dialErr: errors.New("connection refused"), err: true},
+		{name: "logs from an endpoint failing to be dialed", subscribe: subscribeFilterLogs, url: "wss://a", dialErr: errors.New("connection refused"), err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			served := newFakeEndpoint(t, "a", 0, &fakeEthService{})
+			active := &endpoint{Endpoint: Endpoint{Name: "a", URL: tt.url}, health: 1, dial: func() (*ethclient.Client, error) {
+				return served.client, tt.dialErr
+			}}
+			e := newTestRpc(t, active)
+
+			err := tt.subscribe(e)
+
+			if !tt.err {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			if tt.dialErr != nil {
+				assert.Less(t, active.health, 1.0, "the dial failure is reported")
+			}
+		})
+	}
+}
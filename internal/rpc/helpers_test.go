@@ -0,0 +1,137 @@
+package rpc
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+// jsonRPCError is a JSON-RPC error with a code, sent by fakeEthService.
+type jsonRPCError struct {
+	code int
+}
+
+func (e jsonRPCError) Error() string  { return "json-rpc error" }
+func (e jsonRPCError) ErrorCode() int { return e.code }
+
+// fakeEthService serves the eth namespace of an in-process RPC endpoint, whose
+// latest, safe and finalized blocks are 100, 90 and 80. Its methods, but
+// GetBlockByNumber, fail with errs, in order, before succeeding.
+type fakeEthService struct {
+	logs []types.Log
+	errs []error
+	// fork makes the hashes of its blocks differ from the other services ones
+	fork string
+	// behind makes every block not found
+	behind bool
+	calls  atomic.Int32
+}
+
+func (s *fakeEthService) next() error {
+	call := int(s.calls.Add(1)) - 1
+	if call < len(s.errs) {
+		return s.errs[call]
+	}
+	return nil
+}
+
+func (s *fakeEthService) GetLogs(ctx context.Context, query map[string]interface{}) ([]types.Log, error) {
+	if err := s.next(); err != nil {
+		return nil, err
+	}
+	return s.logs, nil
+}
+
+func (s *fakeEthService) GetBlockByNumber(ctx context.Context, number ethrpc.BlockNumber, fullTx bool) (*types.Header, error) {
+	if s.behind {
+		return nil, nil
+	}
+	switch number {
+	case ethrpc.SafeBlockNumber:
+		number = 90
+	case ethrpc.FinalizedBlockNumber:
+		number = 80
+	}
+	return &types.Header{Number: big.NewInt(number.Int64()), Difficulty: big.NewInt(0), Extra: []byte(s.fork)}, nil
+}
+
+func (s *fakeEthService) BlockNumber(ctx context.Context) (hexutil.Uint64, error) {
+	if err := s.next(); err != nil {
+		return 0, err
+	}
+	return 100, nil
+}
+
+// NewHeads serves the new heads subscriptions, without sending any head.
+func (s *fakeEthService) NewHeads(ctx context.Context) (*ethrpc.Subscription, error) {
+	notifier, ok := ethrpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, ethrpc.ErrNotificationsUnsupported
+	}
+	return notifier.CreateSubscription(), nil
+}
+
+// newFakeEndpoint returns an endpoint served in process by the service.
+func newFakeEndpoint(t *testing.T, name string, priority int, service *fakeEthService) *endpoint {
+	t.Helper()
+	server := ethrpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", service))
+	client := ethclient.NewClient(ethrpc.DialInProc(server))
+	t.Cleanup(func() {
+		client.Close()
+		server.Stop()
+	})
+	return &endpoint{Endpoint: Endpoint{Name: name, URL: "https://" + name, Priority: priority}, client: client, health: 1}
+}
+
+// counterValue returns the value of a counter.
+func counterValue(t *testing.T, counter prometheus.Counter) float64 {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(counter))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	return families[0].GetMetric()[0].GetCounter().GetValue()
+}
+
+// newTestEndpoint returns an endpoint without client.
+func newTestEndpoint(name string, url string, priority int) *endpoint {
+	return &endpoint{Endpoint: Endpoint{Name: name, URL: url, Priority: priority}, health: 1}
+}
+
+// newTestRpc returns an RPC client sending its requests to the endpoints,
+// retrying them quickly. The network is named after the test, so the global
+// metrics of each test are distinct.
+func newTestRpc(t *testing.T, endpoints ...*endpoint) *ethEvmRpc {
+	t.Helper()
+	return &ethEvmRpc{
+		network:   t.Name(),
+		endpoints: newEndpointPool(t.Name(), endpoints),
+		retryPolicy: RetryPolicy{
+			InitialInterval: time.Millisecond,
+			MaxInterval:     time.Millisecond,
+			MaxAttempts:     3,
+		}.withDefaults(),
+	}
+}
+
+// histogramCount returns the number of observations of a histogram.
+func histogramCount(t *testing.T, histogram prometheus.Histogram) uint64 {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(histogram))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	return families[0].GetMetric()[0].GetHistogram().GetSampleCount()
+}
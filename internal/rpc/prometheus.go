@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	metricActiveEndpoint = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "rpc_active_endpoint",
+		Help:      "Whether the RPC endpoint is the active endpoint of the network",
+	}, []string{"network", "endpoint"})
+	metricComputeUnits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "rpc_compute_units_total",
+		Help:      "Estimated compute units consumed by the requests sent to the RPC endpoint",
+	}, []string{"network", "endpoint", "method"})
+	metricEndpointHealth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "eoe",
+		Name:      "rpc_endpoint_health",
+		Help:      "Health score of the RPC endpoint, from 0 (failing) to 1 (healthy)",
+	}, []string{"network", "endpoint"})
+	metricEndpointSwitches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "rpc_endpoint_switches_total",
+		Help:      "Number of times the active RPC endpoint of the network changed",
+	}, []string{"network"})
+	metricLogsConsensusDisagreements = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "rpc_logs_consensus_disagreements_total",
+		Help:      "Number of times the RPC endpoint returned logs different from the logs accepted by consensus, or no consensus was reached",
+	}, []string{"network", "endpoint"})
+	metricLogsConsensusFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "rpc_logs_consensus_failures_total",
+		Help:      "Number of block ranges for which the RPC endpoints did not reach a logs consensus",
+	}, []string{"network"})
+	metricRateLimitWait = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "eoe",
+		Name:      "rpc_rate_limit_wait_seconds",
+		Help:      "Time the requests waited for the rate limiter of the RPC endpoint, by priority",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"network", "endpoint", "priority"})
+	metricRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "eoe",
+		Name:      "rpc_request_duration_seconds",
+		Help:      "Latency of the requests sent to the RPC endpoint",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"network", "endpoint", "method"})
+	metricRequestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "rpc_request_errors_total",
+		Help:      "Number of failed requests sent to the RPC endpoint, by error class",
+	}, []string{"network", "endpoint", "method", "class"})
+	metricRequestRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "eoe",
+		Name:      "rpc_request_retries_total",
+		Help:      "Number of retries of failed RPC requests",
+	}, []string{"network", "method"})
+)
@@ -0,0 +1,159 @@
+package rpc
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Priority is the priority class of a request. When an endpoint is rate
+// limited, requests of a higher priority are sent before the waiting requests
+// of a lower priority.
+type Priority int
+
+const (
+	// PriorityHigh is used by requests that keep the exporters up to date,
+	// such as the latest block lookups.
+	PriorityHigh Priority = iota
+	// PriorityNormal is the priority of the requests without a priority.
+	PriorityNormal
+	// PriorityLow is used by bulk requests, such as transaction lookups.
+	PriorityLow
+
+	priorityCount = int(PriorityLow) + 1
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityHigh:
+		return "high"
+	case PriorityLow:
+		return "low"
+	default:
+		return "normal"
+	}
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of the context whose requests have the given
+// priority.
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+func priorityFromContext(ctx context.Context) Priority {
+	if priority, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return priority
+	}
+	return PriorityNormal
+}
+
+// rateLimiter is a token bucket limiting the requests per second sent to an
+// endpoint. A nil rateLimiter does not limit the requests.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	// waiting is the number of requests waiting for a token by priority.
+	waiting [priorityCount]int
+}
+
+// newRateLimiter returns a limiter allowing rps requests per second on
+// average and bursts of up to burst requests. It returns nil if rps is not
+// positive. If burst is not positive, bursts of one second of requests are
+// allowed.
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	b := float64(burst)
+	if burst <= 0 {
+		b = math.Max(1, math.Ceil(rps))
+	}
+	return &rateLimiter{rate: rps, burst: b, tokens: b, last: time.Now()}
+}
+
+var (
+	sharedLimitersMu sync.Mutex
+	// sharedLimiters are the rate limiters of the endpoints by URL
+	sharedLimiters = make(map[string]*rateLimiter)
+)
+
+// sharedRateLimiter returns the rate limiter of the endpoint URL, shared by
+// every RPC client of the process, so the limit applies to the requests of all
+// of them. The limiter is created with the given limits the first time the URL
+// is used.
+func sharedRateLimiter(url string, rps float64, burst int) *rateLimiter {
+	sharedLimitersMu.Lock()
+	defer sharedLimitersMu.Unlock()
+	if l, ok := sharedLimiters[url]; ok {
+		return l
+	}
+	l := newRateLimiter(rps, burst)
+	sharedLimiters[url] = l
+	return l
+}
+
+// wait blocks until a request of the given priority can be sent or the
+// context is done. A token is only taken when no request of a higher priority
+// is waiting.
+func (l *rateLimiter) wait(ctx context.Context, priority Priority) error {
+	if l == nil {
+		return nil
+	}
+	waiting := false
+	for {
+		l.mu.Lock()
+		l.refill()
+		if l.tokens >= 1 && !l.higherWaiting(priority) {
+			l.tokens--
+			if waiting {
+				l.waiting[priority]--
+			}
+			l.mu.Unlock()
+			return nil
+		}
+		if !waiting {
+			l.waiting[priority]++
+			waiting = true
+		}
+		// Wait for the next token. If one is available, it is left to the
+		// higher priority requests.
+		delay := time.Duration(math.Max(1-l.tokens, 1/l.burst) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			l.mu.Lock()
+			l.waiting[priority]--
+			l.mu.Unlock()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// refill adds the tokens earned since the last refill. It must be called with
+// the lock held.
+func (l *rateLimiter) refill() {
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+}
+
+// higherWaiting reports whether a request of a higher priority than the given
+// one is waiting. It must be called with the lock held.
+func (l *rateLimiter) higherWaiting(priority Priority) bool {
+	for p := PriorityHigh; p < priority; p++ {
+		if l.waiting[p] > 0 {
+			return true
+		}
+	}
+	return false
+}
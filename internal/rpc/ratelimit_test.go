@@ -0,0 +1,107 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitingRequests returns the number of requests of the priority waiting for
+// the limiter.
+func waitingRequests(l *rateLimiter, priority Priority) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.waiting[priority]
+}
+
+func TestNewRateLimiter(t *testing.T) {
+	tests := []struct {
+		name  string
+		rps   float64
+		burst int
+		nil   bool
+		want  float64
+	}{
+		{name: "unlimited", rps: 0, nil: true},
+		{name: "default burst", rps: 2.5, want: 3},
+		{name: "default burst under one request", rps: 0.5, want: 1},
+		{name: "burst", rps: 10, burst: 4, want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newRateLimiter(tt.rps, tt.burst)
+			if tt.nil {
+				assert.Nil(t, l)
+				assert.NoError(t, l.wait(context.Background(), PriorityLow), "a nil limiter does not block")
+				return
+			}
+			require.NotNil(t, l)
+			assert.Equal(t, tt.want, l.burst)
+			assert.Equal(t, tt.want, l.tokens, "the bucket starts full")
+		})
+	}
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	l := newRateLimiter(1, 3)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	for range 3 {
+		require.NoError(t, l.wait(ctx, PriorityNormal))
+	}
+	assert.ErrorIs(t, l.wait(ctx, PriorityNormal), context.DeadlineExceeded)
+	assert.Equal(t, 0, waitingRequests(l, PriorityNormal), "canceled requests stop waiting")
+}
+
+func TestRateLimiterPriority(t *testing.T) {
+	l := newRateLimiter(5, 1)
+	require.NoError(t, l.wait(context.Background(), PriorityNormal))
+
+	var (
+		mu    sync.Mutex
+		order []Priority
+		wg    sync.WaitGroup
+	)
+	wait := func(priority Priority) {
+		defer wg.Done()
+		assert.NoError(t, l.wait(context.Background(), priority))
+		mu.Lock()
+		order = append(order, priority)
+		mu.Unlock()
+	}
+	// The low priority request waits first, but the high priority one takes
+	// the next token
+	wg.Add(3)
+	go wait(PriorityLow)
+	require.Eventually(t, func() bool { return waitingRequests(l, PriorityLow) == 1 }, time.Second, time.Millisecond)
+	go wait(PriorityNormal)
+	require.Eventually(t, func() bool { return waitingRequests(l, PriorityNormal) == 1 }, time.Second, time.Millisecond)
+	go wait(PriorityHigh)
+	wg.Wait()
+
+	assert.Equal(t, []Priority{PriorityHigh, PriorityNormal, PriorityLow}, order)
+}
+
+func TestPriorityFromContext(t *testing.T) {
+	assert.Equal(t, PriorityNormal, priorityFromContext(context.Background()))
+	assert.Equal(t, PriorityHigh, priorityFromContext(WithPriority(context.Background(), PriorityHigh)))
+	assert.Equal(t, PriorityLow, priorityFromContext(WithPriority(context.Background(), PriorityLow)))
+	assert.Equal(t, "high", PriorityHigh.String())
+	assert.Equal(t, "normal", PriorityNormal.String())
+	assert.Equal(t, "low", PriorityLow.String())
+}
+
+func TestSharedRateLimiter(t *testing.T) {
+	url := "https://" + t.Name()
+	l := sharedRateLimiter(url, 5, 1)
+	require.NotNil(t, l)
+
+	assert.Same(t, l, sharedRateLimiter(url, 5, 1), "the clients of an endpoint share its limiter")
+	assert.Same(t, l, sharedRateLimiter(url, 10, 2), "the first limits of the endpoint are kept")
+	assert.NotSame(t, l, sharedRateLimiter(url+"/other", 5, 1))
+	assert.Nil(t, sharedRateLimiter(url+"/unlimited", 0, 0))
+}
@@ -0,0 +1,69 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// RetryPolicy is the exponential backoff policy of the failed RPC requests.
+type RetryPolicy struct {
+	// InitialInterval is the wait time before the first retry.
+	InitialInterval time.Duration
+	// Multiplier is the factor the wait time grows by after each retry.
+	Multiplier float64
+	// MaxInterval caps the wait time between two retries.
+	MaxInterval time.Duration
+	// MaxElapsedTime is the time after which a request is not retried anymore.
+	MaxElapsedTime time.Duration
+	// MaxAttempts is the maximum number of attempts of a request, including
+	// the first one.
+	MaxAttempts uint64
+}
+
+// DefaultRetryPolicy returns the retry policy used for the fields of a policy
+// that are not set.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		InitialInterval: 500 * time.Millisecond,
+		Multiplier:      1.5,
+		MaxInterval:     10 * time.Second,
+		MaxElapsedTime:  time.Minute,
+		MaxAttempts:     5,
+	}
+}
+
+// withDefaults returns the policy with the fields that are not set taken from
+// the default policy.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	defaults := DefaultRetryPolicy()
+	if p.InitialInterval == 0 {
+		p.InitialInterval = defaults.InitialInterval
+	}
+	if p.Multiplier == 0 {
+		p.Multiplier = defaults.Multiplier
+	}
+	if p.MaxInterval == 0 {
+		p.MaxInterval = defaults.MaxInterval
+	}
+	if p.MaxElapsedTime == 0 {
+		p.MaxElapsedTime = defaults.MaxElapsedTime
+	}
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = defaults.MaxAttempts
+	}
+	return p
+}
+
+// backOff returns a new backoff following the policy, stopped when the context
+// is done.
+func (p RetryPolicy) backOff(ctx context.Context) backoff.BackOff {
+	b := backoff.NewExponentialBackOff(
+		backoff.WithInitialInterval(p.InitialInterval),
+		backoff.WithMultiplier(p.Multiplier),
+		backoff.WithMaxInterval(p.MaxInterval),
+		backoff.WithMaxElapsedTime(p.MaxElapsedTime),
+	)
+	return backoff.WithContext(backoff.WithMaxRetries(b, p.MaxAttempts-1), ctx)
+}
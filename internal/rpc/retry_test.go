@@ -0,0 +1,59 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		errs    []error
+		calls   int32
+		retries float64
+		err     bool
+	}{
+		{name: "success", calls: 1},
+		{name: "transient errors", errs: []error{errors.New("connection reset"), jsonRPCError{code: jsonRPCLimitExceeded}}, calls: 3, retries: 2},
+		{name: "permanent error", errs: []error{jsonRPCError{code: -32602}}, calls: 1, err: true},
+		{name: "max attempts", errs: []error{errors.New("a"), errors.New("b"), errors.New("c"), errors.New("d")}, calls: 3, retries: 2, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			network := t.Name()
+			service := &fakeEthService{errs: tt.errs}
+			e := newTestRpc(t, newFakeEndpoint(t, "a", 0, service))
+
+			// The metric is global, so only its increase is checked
+			retries := counterValue(t, metricRequestRetries.WithLabelValues(network, "eth_blockNumber"))
+
+			blockNumber, err := e.BlockNumber(context.Background())
+
+			if tt.err {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, uint64(100), blockNumber)
+			}
+			assert.Equal(t, tt.calls, service.calls.Load())
+			assert.Equal(t, retries+tt.retries, counterValue(t, metricRequestRetries.WithLabelValues(network, "eth_blockNumber")))
+		})
+	}
+}
+
+func TestRetryPolicyWithDefaults(t *testing.T) {
+	defaults := DefaultRetryPolicy()
+	assert.Equal(t, defaults, RetryPolicy{}.withDefaults())
+
+	policy := RetryPolicy{InitialInterval: time.Second, MaxAttempts: 10}.withDefaults()
+	assert.Equal(t, time.Second, policy.InitialInterval)
+	assert.Equal(t, uint64(10), policy.MaxAttempts)
+	assert.Equal(t, defaults.Multiplier, policy.Multiplier)
+	assert.Equal(t, defaults.MaxInterval, policy.MaxInterval)
+	assert.Equal(t, defaults.MaxElapsedTime, policy.MaxElapsedTime)
+}
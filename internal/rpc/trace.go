@@ -0,0 +1,36 @@
+package rpc
+
+import (
+	"context"
+	"log/slog"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// CallFrame is a call of a transaction, as returned by the call tracer of
+// debug_traceTransaction.
+type CallFrame struct {
+	Type  string             `json:"type"`
+	From  ethcommon.Address  `json:"from"`
+	To    *ethcommon.Address `json:"to,omitempty"`
+	Input hexutil.Bytes      `json:"input"`
+	// Error is set if the call reverted.
+	Error string      `json:"error,omitempty"`
+	Calls []CallFrame `json:"calls,omitempty"`
+}
+
+// TraceTransaction returns the call tree of the transaction. It requires an
+// RPC endpoint exposing the debug namespace.
+func (e *ethEvmRpc) TraceTransaction(ctx context.Context, hash ethcommon.Hash) (*CallFrame, error) {
+	return call(ctx, e, "debug_traceTransaction", func(client *ethclient.Client) (*CallFrame, error) {
+		slog.Debug("tracing transaction |", "rpc-network", e.network, "hash", hash)
+		var frame CallFrame
+		err := client.Client().CallContext(ctx, &frame, "debug_traceTransaction", hash, map[string]string{"tracer": "callTracer"})
+		if err != nil {
+			return nil, err
+		}
+		return &frame, nil
+	})
+}